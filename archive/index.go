@@ -0,0 +1,327 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	kjson "github.com/kurrik/json"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
+)
+
+const (
+	// Every unique Tweet, one JSON object per line.
+	tweetsFile = "tweets.ndjson"
+	// The gob encoded indexData.
+	indexFile = "index.gob"
+)
+
+// Everything needed to search tweetsFile.  Tweets are numbered in the order
+// they were added, and each posting list holds ascending Tweet numbers.
+type indexData struct {
+	Ids      []uint64
+	Offsets  []int64
+	Lengths  []int32
+	Times    []int64
+	Size     int64
+	Terms    map[string][]uint32
+	Users    map[string][]uint32
+	Hashtags map[string][]uint32
+	Langs    map[string][]uint32
+}
+
+// An index of Tweets stored in a directory.  Open one, Add Tweets to it,
+// and Save it to make them searchable the next time it is opened.
+type Index struct {
+	dir    string
+	data   indexData
+	byId   map[uint64]uint32
+	tweets *os.File
+}
+
+// Opens the index in dir, creating it if it does not exist yet.
+func Open(dir string) (ix *Index, err error) {
+	var f *os.File
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	ix = &Index{
+		dir: dir,
+		data: indexData{
+			Terms:    map[string][]uint32{},
+			Users:    map[string][]uint32{},
+			Hashtags: map[string][]uint32{},
+			Langs:    map[string][]uint32{},
+		},
+		byId: map[uint64]uint32{},
+	}
+	if f, err = os.Open(filepath.Join(dir, indexFile)); err == nil {
+		err = gob.NewDecoder(f).Decode(&ix.data)
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("Could not read index: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	for n, id := range ix.data.Ids {
+		ix.byId[id] = uint32(n)
+	}
+	if ix.tweets, err = os.OpenFile(filepath.Join(dir, tweetsFile), os.O_RDWR|os.O_CREATE, 0644); err != nil {
+		return nil, err
+	}
+	// Drop anything written after the last Save, since it is not indexed.
+	if err = ix.tweets.Truncate(ix.data.Size); err != nil {
+		ix.tweets.Close()
+		return nil, err
+	}
+	return
+}
+
+func (ix *Index) Close() error {
+	return ix.tweets.Close()
+}
+
+// Returns the number of Tweets in the index.
+func (ix *Index) Len() int {
+	return len(ix.data.Ids)
+}
+
+// Returns the times of the oldest and newest Tweets in the index.
+func (ix *Index) Span() (oldest time.Time, newest time.Time) {
+	for n, t := range ix.data.Times {
+		if n == 0 || t < oldest.Unix() {
+			oldest = time.Unix(t, 0)
+		}
+		if n == 0 || t > newest.Unix() {
+			newest = time.Unix(t, 0)
+		}
+	}
+	return
+}
+
+// Returns the number of distinct authors in the index.
+func (ix *Index) Users() int {
+	return len(ix.data.Users)
+}
+
+func post(postings map[string][]uint32, key string, n uint32) {
+	if key != "" {
+		postings[key] = append(postings[key], n)
+	}
+}
+
+// Adds tweet to the index.  Returns false without adding anything if a
+// Tweet with the same ID is already indexed, or if tweet has no ID or author.
+func (ix *Index) Add(tweet twittergo.Tweet) (added bool, err error) {
+	var text []byte
+	id := tweet.Id()
+	if id == 0 || tweet["user"] == nil {
+		return
+	}
+	if _, ok := ix.byId[id]; ok {
+		return
+	}
+	if text, err = json.Marshal(tweet); err != nil {
+		return
+	}
+	text = append(text, '\n')
+	if _, err = ix.tweets.WriteAt(text, ix.data.Size); err != nil {
+		return
+	}
+	n := uint32(len(ix.data.Ids))
+	ix.byId[id] = n
+	ix.data.Ids = append(ix.data.Ids, id)
+	ix.data.Offsets = append(ix.data.Offsets, ix.data.Size)
+	ix.data.Lengths = append(ix.data.Lengths, int32(len(text)))
+	ix.data.Times = append(ix.data.Times, tweet.CreatedAt().Unix())
+	ix.data.Size += int64(len(text))
+
+	seen := map[string]bool{}
+	for _, term := range Tokens(output.TweetText(tweet)) {
+		if !seen[term] {
+			seen[term] = true
+			post(ix.data.Terms, term, n)
+		}
+	}
+	post(ix.data.Users, strings.ToLower(tweet.User().ScreenName()), n)
+	post(ix.data.Langs, strings.ToLower(output.Value(tweet, "lang")), n)
+	entities, _ := output.TweetEntities(tweet)
+	seen = map[string]bool{}
+	for _, item := range output.Array(entities, "hashtags") {
+		if obj, ok := item.(map[string]interface{}); ok {
+			tag := strings.ToLower(output.Value(obj, "text"))
+			if !seen[tag] {
+				seen[tag] = true
+				post(ix.data.Hashtags, tag, n)
+			}
+		}
+	}
+	added = true
+	return
+}
+
+// Writes the index so that Tweets added since it was opened are kept.
+func (ix *Index) Save() (err error) {
+	var f *os.File
+	if err = ix.tweets.Sync(); err != nil {
+		return
+	}
+	path := filepath.Join(ix.dir, indexFile)
+	if f, err = os.Create(path + ".tmp"); err != nil {
+		return
+	}
+	if err = gob.NewEncoder(f).Encode(&ix.data); err != nil {
+		f.Close()
+		return
+	}
+	if err = f.Close(); err != nil {
+		return
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Reads the n-th Tweet added to the index.
+func (ix *Index) Tweet(n uint32) (tweet twittergo.Tweet, err error) {
+	text := make([]byte, ix.data.Lengths[n])
+	if _, err = ix.tweets.ReadAt(text, ix.data.Offsets[n]); err != nil && err != io.EOF {
+		return
+	}
+	tweet = twittergo.Tweet{}
+	err = kjson.Unmarshal(text, &tweet)
+	return
+}
+
+// Describes the Tweets to find.  Blank fields match everything.
+type Query struct {
+	// Words which must all appear in the text, in any order.
+	Terms []string
+	// Words which must not appear in the text.
+	Exclude []string
+	// Screen name of the author, without the @.
+	User string
+	// Hashtag, without the #.
+	Hashtag string
+	// Language code, like "en".
+	Lang string
+	// Only Tweets created at or after Since and before Until.
+	Since time.Time
+	Until time.Time
+	// Maximum number of results, or 0 for all of them.
+	Limit int
+}
+
+// Returns the numbers of the Tweets matching q, newest first.
+func (ix *Index) Search(q *Query) (results []uint32) {
+	var (
+		candidates []uint32
+		filtered   bool
+	)
+	require := func(postings []uint32) {
+		if !filtered {
+			candidates = postings
+			filtered = true
+		} else {
+			candidates = intersect(candidates, postings)
+		}
+	}
+	for _, term := range q.Terms {
+		for _, token := range Tokens(term) {
+			require(ix.data.Terms[token])
+		}
+	}
+	if q.User != "" {
+		require(ix.data.Users[strings.ToLower(strings.TrimPrefix(q.User, "@"))])
+	}
+	if q.Hashtag != "" {
+		require(ix.data.Hashtags[strings.ToLower(strings.TrimPrefix(q.Hashtag, "#"))])
+	}
+	if q.Lang != "" {
+		require(ix.data.Langs[strings.ToLower(q.Lang)])
+	}
+	if !filtered {
+		candidates = make([]uint32, len(ix.data.Ids))
+		for n := range candidates {
+			candidates[n] = uint32(n)
+		}
+	}
+	for _, term := range q.Exclude {
+		for _, token := range Tokens(term) {
+			candidates = subtract(candidates, ix.data.Terms[token])
+		}
+	}
+	for _, n := range candidates {
+		t := ix.data.Times[n]
+		if !q.Since.IsZero() && t < q.Since.Unix() {
+			continue
+		}
+		if !q.Until.IsZero() && t >= q.Until.Unix() {
+			continue
+		}
+		results = append(results, n)
+	}
+	sort.Slice(results, func(i, j int) bool {
+		ti, tj := ix.data.Times[results[i]], ix.data.Times[results[j]]
+		if ti != tj {
+			return ti > tj
+		}
+		return ix.data.Ids[results[i]] > ix.data.Ids[results[j]]
+	})
+	if q.Limit > 0 && len(results) > q.Limit {
+		results = results[:q.Limit]
+	}
+	return
+}
+
+// Returns the numbers in both ascending lists.
+func intersect(a []uint32, b []uint32) (out []uint32) {
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return
+}
+
+// Returns the numbers in ascending list a which are not in b.
+func subtract(a []uint32, b []uint32) (out []uint32) {
+	j := 0
+	for _, n := range a {
+		for j < len(b) && b[j] < n {
+			j++
+		}
+		if j < len(b) && b[j] == n {
+			continue
+		}
+		out = append(out, n)
+	}
+	return
+}
@@ -0,0 +1,225 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package archive
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/kurrik/twittergo"
+)
+
+var epoch = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func testTweet(id uint64, user string, minutes int, lang string, text string, tags ...string) twittergo.Tweet {
+	hashtags := []interface{}{}
+	for _, tag := range tags {
+		hashtags = append(hashtags, map[string]interface{}{"text": tag})
+	}
+	return twittergo.Tweet{
+		"id_str":     fmt.Sprintf("%v", id),
+		"created_at": epoch.Add(time.Duration(minutes) * time.Minute).Format(time.RubyDate),
+		"text":       text,
+		"lang":       lang,
+		"user":       map[string]interface{}{"screen_name": user},
+		"entities":   map[string]interface{}{"hashtags": hashtags},
+	}
+}
+
+func testIndex(t *testing.T) (ix *Index, dir string) {
+	var err error
+	if dir, err = ioutil.TempDir("", "archive"); err != nil {
+		t.Fatal(err)
+	}
+	if ix, err = Open(dir); err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"Hello, World!", []string{"hello", "world"}},
+		{"Don't stop", []string{"dont", "stop"}},
+		{"Don’t stop", []string{"dont", "stop"}},
+		{"see https://t.co/abc123 now", []string{"see", "now"}},
+		{"fish &amp; chips", []string{"fish", "chips"}},
+		{"snake_case and 2026", []string{"snake_case", "and", "2026"}},
+		{"東京tower", []string{"東", "京", "tower"}},
+		{"#golang @kurrik", []string{"golang", "kurrik"}},
+	}
+	for _, test := range tests {
+		if got := Tokens(test.text); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Tokens(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
+
+func TestPostings(t *testing.T) {
+	tests := []struct {
+		a, b      []uint32
+		intersect []uint32
+		subtract  []uint32
+	}{
+		{nil, nil, nil, nil},
+		{[]uint32{1, 2, 3}, nil, nil, []uint32{1, 2, 3}},
+		{nil, []uint32{1, 2, 3}, nil, nil},
+		{[]uint32{1, 2, 3}, []uint32{2}, []uint32{2}, []uint32{1, 3}},
+		{[]uint32{1, 3, 5, 7}, []uint32{2, 3, 4, 7, 9}, []uint32{3, 7}, []uint32{1, 5}},
+		{[]uint32{4, 5}, []uint32{1, 2}, nil, []uint32{4, 5}},
+	}
+	for _, test := range tests {
+		if got := intersect(test.a, test.b); !reflect.DeepEqual(got, test.intersect) {
+			t.Errorf("intersect(%v, %v) = %v, want %v", test.a, test.b, got, test.intersect)
+		}
+		if got := subtract(test.a, test.b); !reflect.DeepEqual(got, test.subtract) {
+			t.Errorf("subtract(%v, %v) = %v, want %v", test.a, test.b, got, test.subtract)
+		}
+	}
+}
+
+func TestAdd(t *testing.T) {
+	ix, dir := testIndex(t)
+	defer os.RemoveAll(dir)
+	defer ix.Close()
+	noUser := testTweet(3, "", 0, "en", "no author")
+	delete(noUser, "user")
+	tests := []struct {
+		name  string
+		tweet twittergo.Tweet
+		added bool
+		len   int
+	}{
+		{"new", testTweet(1, "alice", 0, "en", "first"), true, 1},
+		{"duplicate", testTweet(1, "alice", 0, "en", "first again"), false, 1},
+		{"second", testTweet(2, "bob", 1, "en", "second"), true, 2},
+		{"no id", testTweet(0, "carol", 2, "en", "no id"), false, 2},
+		{"no user", noUser, false, 2},
+	}
+	for _, test := range tests {
+		added, err := ix.Add(test.tweet)
+		if err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		if added != test.added || ix.Len() != test.len {
+			t.Errorf("%v: added %v with %v Tweets, want %v with %v", test.name, added, ix.Len(), test.added, test.len)
+		}
+	}
+	tweet, err := ix.Tweet(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tweet.Id() != 2 || tweet.Text() != "second" {
+		t.Errorf("Tweet(1) = %v %q, want 2 \"second\"", tweet.Id(), tweet.Text())
+	}
+}
+
+func TestOpen(t *testing.T) {
+	ix, dir := testIndex(t)
+	defer os.RemoveAll(dir)
+	steps := []struct {
+		tweet twittergo.Tweet
+		save  bool
+	}{
+		{testTweet(1, "alice", 0, "en", "saved"), false},
+		{testTweet(2, "alice", 1, "en", "saved too"), true},
+		{testTweet(3, "alice", 2, "en", "never saved"), false},
+	}
+	for _, step := range steps {
+		if _, err := ix.Add(step.tweet); err != nil {
+			t.Fatal(err)
+		}
+		if step.save {
+			if err := ix.Save(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	ix.Close()
+	ix, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ix.Close()
+	if ix.Len() != 2 {
+		t.Errorf("Len() = %v after reopening, want 2", ix.Len())
+	}
+	info, err := os.Stat(filepath.Join(dir, tweetsFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != ix.data.Size {
+		t.Errorf("%v is %v bytes, want the saved %v", tweetsFile, info.Size(), ix.data.Size)
+	}
+	// The unsaved Tweet was dropped, so it can be added again.
+	if added, err := ix.Add(steps[2].tweet); err != nil || !added {
+		t.Errorf("Add after reopening = %v, %v, want true", added, err)
+	}
+	if results := ix.Search(&Query{Terms: []string{"saved"}}); len(results) != 3 {
+		t.Errorf("Search(saved) = %v, want 3 results", results)
+	}
+}
+
+func TestSearch(t *testing.T) {
+	ix, dir := testIndex(t)
+	defer os.RemoveAll(dir)
+	defer ix.Close()
+	tweets := []twittergo.Tweet{
+		testTweet(10, "alice", 0, "en", "Learning Go today", "golang"),
+		testTweet(11, "bob", 10, "en", "Go is fun, don't you think?", "GoLang", "fun"),
+		testTweet(12, "Alice", 20, "ja", "東京で Go", "golang"),
+		testTweet(13, "carol", 30, "en", "Nothing to see https://example.com/go"),
+	}
+	for _, tweet := range tweets {
+		if _, err := ix.Add(tweet); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		name  string
+		query Query
+		want  []uint32
+	}{
+		{"everything newest first", Query{}, []uint32{3, 2, 1, 0}},
+		{"term", Query{Terms: []string{"go"}}, []uint32{2, 1, 0}},
+		{"all terms", Query{Terms: []string{"go", "fun"}}, []uint32{1}},
+		{"apostrophe", Query{Terms: []string{"dont"}}, []uint32{1}},
+		{"cjk", Query{Terms: []string{"東京"}}, []uint32{2}},
+		{"exclude", Query{Terms: []string{"go"}, Exclude: []string{"fun"}}, []uint32{2, 0}},
+		{"user", Query{User: "@ALICE"}, []uint32{2, 0}},
+		{"hashtag", Query{Hashtag: "#golang"}, []uint32{2, 1, 0}},
+		{"lang", Query{Lang: "JA"}, []uint32{2}},
+		{"since", Query{Since: epoch.Add(10 * time.Minute)}, []uint32{3, 2, 1}},
+		{"until", Query{Until: epoch.Add(10 * time.Minute)}, []uint32{0}},
+		{"limit", Query{Limit: 2}, []uint32{3, 2}},
+		{"unknown term", Query{Terms: []string{"python"}}, nil},
+		{"unknown user", Query{User: "dave"}, nil},
+	}
+	for _, test := range tests {
+		if got := ix.Search(&test.query); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: Search = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Works with the Tweet archives written by the other examples.
+//
+// Two layouts are understood.  NDJSON, with one Tweet object per line, is
+// written by user_timeline, favorites, tweet_hydrate and the list exports.
+// Older versions of tweet_hydrate wrote one "<id>\t<json>" pair per line,
+// with null for Tweets which no longer exist.  Either may be gzipped if the
+// file name ends in ".gz".
+// Objects without an id_str, such as the delete and limit notices found in
+// streams, are skipped.
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kurrik/json"
+	"github.com/kurrik/twittergo"
+)
+
+// Calls handler with every Tweet in r, stopping at the first error.
+func Read(r io.Reader, handler func(twittergo.Tweet) error) (err error) {
+	var (
+		reader = bufio.NewReader(r)
+		line   []byte
+		lineno int
+	)
+	for {
+		line, err = reader.ReadBytes('\n')
+		if err == io.EOF {
+			err = nil
+			if len(line) == 0 {
+				break
+			}
+		} else if err != nil {
+			return
+		}
+		lineno++
+		line = bytes.TrimSpace(line)
+		if len(line) > 0 && line[0] != '{' {
+			// tweet_hydrate layout, drop the ID column.
+			if i := bytes.IndexByte(line, '\t'); i >= 0 {
+				line = bytes.TrimSpace(line[i+1:])
+			}
+		}
+		if len(line) == 0 || string(line) == "null" {
+			continue
+		}
+		tweet := twittergo.Tweet{}
+		if err = json.Unmarshal(line, &tweet); err != nil {
+			return fmt.Errorf("Line %v: %v", lineno, err)
+		}
+		if tweet["id_str"] == nil {
+			// Not a Tweet, like the delete notices in streams.
+			continue
+		}
+		if err = handler(tweet); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Calls handler with every Tweet in the archive at path.
+func ReadFile(path string, handler func(twittergo.Tweet) error) (err error) {
+	var (
+		f *os.File
+		r io.Reader
+	)
+	if f, err = os.Open(path); err != nil {
+		return
+	}
+	defer f.Close()
+	r = f
+	if strings.HasSuffix(path, ".gz") {
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(f); err != nil {
+			return fmt.Errorf("%v: %v", path, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+	if err = Read(r, handler); err != nil {
+		err = fmt.Errorf("%v: %v", path, err)
+	}
+	return
+}
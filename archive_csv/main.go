@@ -0,0 +1,105 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Converts Tweet archives into CSV for spreadsheets.
+package main
+
+// Reads the files written by user_timeline, favorites or tweet_hydrate and
+// writes one row per Tweet, using the flattened schema documented on
+// output.FlatTweet.  No credentials are needed.
+//
+//   $ go run ./user_timeline -screen_name=kurrik
+//   $ go run ./archive_csv -out=kurrik.csv user_timeline.json
+//   Read 3036 Tweets from user_timeline.json
+//   --------------------------------------------------------
+//   Wrote 3036 Tweets to kurrik.csv
+//
+// Several archives may be passed at once.  Use -output=tsv for tab separated
+// values, or any other output format.
+
+import (
+	"flag"
+	"fmt"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/archive"
+	"github.com/kurrik/twittergo-examples/output"
+	"os"
+)
+
+type Args struct {
+	OutputFile string
+	Output     string
+	Template   string
+	Inputs     []string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.OutputFile, "out", "", "Output file, stdout if blank")
+	flag.StringVar(&a.Output, "output", output.CSV, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
+	flag.Parse()
+	a.Inputs = flag.Args()
+	return a
+}
+
+func main() {
+	var (
+		err   error
+		args  *Args
+		out   *os.File
+		w     *output.Writer
+		total int
+	)
+	args = parseArgs()
+	if len(args.Inputs) == 0 {
+		fmt.Fprintf(os.Stderr, "Pass the archives to convert as arguments.\n")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	out = os.Stdout
+	if args.OutputFile != "" {
+		if out, err = os.Create(args.OutputFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not create output file %v: %v\n", args.OutputFile, err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+	if w, err = output.NewWriter(out, args.Output, args.Template, output.TweetSchema); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	for _, path := range args.Inputs {
+		count := 0
+		err = archive.ReadFile(path, func(tweet twittergo.Tweet) error {
+			count++
+			return w.Write(tweet)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not convert %v: %v\n", path, err)
+			w.Close()
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Read %v Tweets from %v\n", count, path)
+		total += count
+	}
+	if err = w.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not finish output: %v\n", err)
+		os.Exit(1)
+	}
+	if args.OutputFile != "" {
+		fmt.Fprintf(os.Stderr, "--------------------------------------------------------\n")
+		fmt.Fprintf(os.Stderr, "Wrote %v Tweets to %v\n", total, args.OutputFile)
+	}
+}
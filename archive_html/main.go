@@ -0,0 +1,416 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Generates a static HTML site from downloaded Tweets.
+package main
+
+// Reads the files written by user_timeline, favorites or tweet_hydrate and
+// writes a browsable copy of them to the -out directory.  No credentials
+// are needed:
+//   $ go run ./user_timeline -screen_name=kurrik
+//   $ go run ./archive_html -out=site user_timeline.json
+//   Read 3036 Tweets from user_timeline.json
+//   Copied 214 media files, 0 failed.
+//   --------------------------------------------------------
+//   Wrote 3036 Tweets in 118 pages to site
+//
+// index.html lists every month, and each month is split into pages of
+// -per_page Tweets, newest first.  Photos, videos and GIFs are copied into
+// site/media so the archive keeps working after they are deleted from
+// Twitter; run with -media=false to link to the copies on Twitter instead.
+// Files already in site/media are not downloaded again.
+//
+// search.html searches the prebuilt search.json in the browser.  Browsers
+// refuse to load it from file:// pages, so serve the directory to search:
+//   $ cd site && python3 -m http.server
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/archive"
+	"github.com/kurrik/twittergo-examples/render"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+)
+
+const (
+	MONTHFORMAT = "2006-01"
+	TIMEFORMAT  = "15:04 MST, Jan 2, 2006"
+	// Results shown at once on the search page.
+	SEARCHLIMIT = 200
+)
+
+type Args struct {
+	Dir     string
+	Title   string
+	PerPage int
+	Media   bool
+	Inputs  []string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Dir, "out", "archive_html", "Directory to write the site to")
+	flag.StringVar(&a.Title, "title", "", "Title of the site, defaults to the first author")
+	flag.IntVar(&a.PerPage, "per_page", 100, "Tweets per page")
+	flag.BoolVar(&a.Media, "media", true, "Copy photos and videos into the site")
+	flag.Parse()
+	a.Inputs = flag.Args()
+	return a
+}
+
+// A Tweet as shown on a page.  Retweets show the original Tweet.
+type TweetView struct {
+	Id          string
+	Name        string
+	ScreenName  string
+	HTML        template.HTML
+	Media       []render.Media
+	Permalink   string
+	Time        string
+	RetweetedBy string
+}
+
+type Page struct {
+	Archive string
+	Title   string
+	Heading string
+	Href    string
+	Newer   string
+	Older   string
+	Tweets  []*TweetView
+	// The range of Tweets shown.
+	first int
+	last  int
+}
+
+type Month struct {
+	Name  string
+	Href  string
+	Count int
+}
+
+type Year struct {
+	Year   int
+	Months []*Month
+}
+
+type Index struct {
+	Archive string
+	Title   string
+	Total   int
+	Years   []*Year
+}
+
+type Search struct {
+	Archive string
+	Title   string
+	Limit   int
+}
+
+// An entry of search.json.
+type SearchEntry struct {
+	Id   string `json:"id"`
+	User string `json:"user"`
+	Date string `json:"date"`
+	Text string `json:"text"`
+	Page string `json:"page"`
+}
+
+// Copies remote media into dir/media.
+type MediaCopier struct {
+	dir    string
+	copied int
+	failed int
+}
+
+// Downloads remote unless it was copied before, and returns the path to use
+// in pages.  Returns remote if the download fails, so pages still work.
+func (m *MediaCopier) Copy(remote string) string {
+	var (
+		u    *url.URL
+		resp *http.Response
+		out  *os.File
+		err  error
+	)
+	if remote == "" {
+		return remote
+	}
+	if u, err = url.Parse(remote); err != nil {
+		return remote
+	}
+	// Different URLs often end in the same name, like video.mp4, so the name
+	// is prefixed with a hash of the whole URL.
+	sum := sha1.Sum([]byte(remote))
+	local := path.Join("media", fmt.Sprintf("%x-%v", sum[:6], path.Base(u.Path)))
+	dest := filepath.Join(m.dir, filepath.FromSlash(local))
+	if _, err = os.Stat(dest); err == nil {
+		return local
+	}
+	if resp, err = http.Get(remote); err == nil {
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			err = fmt.Errorf("HTTP status %v", resp.Status)
+		}
+	}
+	if err == nil {
+		out, err = os.Create(dest + ".tmp")
+	}
+	if err == nil {
+		_, err = io.Copy(out, resp.Body)
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+		if err == nil {
+			err = os.Rename(dest+".tmp", dest)
+		}
+		if err != nil {
+			os.Remove(dest + ".tmp")
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not copy %v: %v\n", remote, err)
+		m.failed++
+		return remote
+	}
+	m.copied++
+	return local
+}
+
+func readTweets(inputs []string) (tweets []twittergo.Tweet, err error) {
+	seen := map[string]bool{}
+	for _, input := range inputs {
+		count := 0
+		err = archive.ReadFile(input, func(tweet twittergo.Tweet) error {
+			if !seen[tweet.IdStr()] {
+				seen[tweet.IdStr()] = true
+				tweets = append(tweets, tweet)
+				count++
+			}
+			return nil
+		})
+		if err != nil {
+			err = fmt.Errorf("Could not read %v: %v", input, err)
+			return
+		}
+		fmt.Printf("Read %v Tweets from %v\n", count, input)
+	}
+	sort.Slice(tweets, func(i, j int) bool {
+		ti, tj := tweets[i].CreatedAt(), tweets[j].CreatedAt()
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return tweets[i].Id() > tweets[j].Id()
+	})
+	return
+}
+
+func view(tweet twittergo.Tweet, media *MediaCopier) *TweetView {
+	v := &TweetView{Id: tweet.IdStr()}
+	if rt, ok := tweet["retweeted_status"].(map[string]interface{}); ok {
+		v.RetweetedBy = tweet.User().ScreenName()
+		tweet = twittergo.Tweet(rt)
+	}
+	user := tweet.User()
+	v.Name = user.Name()
+	v.ScreenName = user.ScreenName()
+	v.HTML = render.HTML(tweet)
+	v.Permalink = render.Permalink(tweet)
+	v.Time = tweet.CreatedAt().UTC().Format(TIMEFORMAT)
+	v.Media = render.AllMedia(tweet)
+	if media != nil {
+		for i := range v.Media {
+			v.Media[i].Image = media.Copy(v.Media[i].Image)
+			v.Media[i].Video = media.Copy(v.Media[i].Video)
+		}
+	}
+	return v
+}
+
+func pageHref(month string, n int) string {
+	if n == 1 {
+		return month + ".html"
+	}
+	return fmt.Sprintf("%v-%v.html", month, n)
+}
+
+// Splits tweets, newest first, into pages of at most perPage Tweets which
+// never span two months.
+func paginate(tweets []twittergo.Tweet, perPage int) (pages []*Page, index *Index, entries []*SearchEntry) {
+	var (
+		start = 0
+		month *Month
+		year  *Year
+	)
+	index = &Index{Total: len(tweets)}
+	for start < len(tweets) {
+		created := tweets[start].CreatedAt().UTC()
+		key := created.Format(MONTHFORMAT)
+		end := start
+		for end < len(tweets) && tweets[end].CreatedAt().UTC().Format(MONTHFORMAT) == key {
+			end++
+		}
+		if year == nil || year.Year != created.Year() {
+			year = &Year{Year: created.Year()}
+			index.Years = append(index.Years, year)
+		}
+		month = &Month{Name: created.Format("January 2006"), Href: pageHref(key, 1), Count: end - start}
+		year.Months = append(year.Months, month)
+		total := (end - start + perPage - 1) / perPage
+		for n := 1; n <= total; n++ {
+			page := &Page{Heading: month.Name, Href: pageHref(key, n)}
+			if total > 1 {
+				page.Heading = fmt.Sprintf("%v, page %v of %v", month.Name, n, total)
+			}
+			page.first = start + (n-1)*perPage
+			page.last = page.first + perPage
+			if page.last > end {
+				page.last = end
+			}
+			for _, tweet := range tweets[page.first:page.last] {
+				original := tweet
+				if rt, ok := tweet["retweeted_status"].(map[string]interface{}); ok {
+					original = twittergo.Tweet(rt)
+				}
+				entries = append(entries, &SearchEntry{
+					Id:   tweet.IdStr(),
+					User: original.User().ScreenName(),
+					Date: tweet.CreatedAt().UTC().Format("2006-01-02"),
+					Text: render.Text(original),
+					Page: page.Href,
+				})
+			}
+			pages = append(pages, page)
+		}
+		start = end
+	}
+	for i, page := range pages {
+		if i > 0 {
+			page.Newer = pages[i-1].Href
+		}
+		if i < len(pages)-1 {
+			page.Older = pages[i+1].Href
+		}
+	}
+	return
+}
+
+func writeTemplate(dir string, name string, tmpl *template.Template, data interface{}) (err error) {
+	var out *os.File
+	if out, err = os.Create(filepath.Join(dir, name)); err != nil {
+		return
+	}
+	if err = tmpl.Execute(out, data); err != nil {
+		out.Close()
+		return fmt.Errorf("Could not render %v: %v", name, err)
+	}
+	return out.Close()
+}
+
+func parseTemplate(layout *template.Template, text string) *template.Template {
+	return template.Must(template.Must(layout.Clone()).Parse(text))
+}
+
+func main() {
+	var (
+		err     error
+		args    *Args
+		tweets  []twittergo.Tweet
+		pages   []*Page
+		index   *Index
+		entries []*SearchEntry
+		media   *MediaCopier
+		data    []byte
+	)
+	args = parseArgs()
+	if len(args.Inputs) == 0 {
+		fmt.Fprintf(os.Stderr, "Pass the archives to publish as arguments.\n")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	if args.PerPage < 1 {
+		fmt.Fprintf(os.Stderr, "-per_page must be at least 1.\n")
+		os.Exit(1)
+	}
+	if tweets, err = readTweets(args.Inputs); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	if args.Title == "" {
+		args.Title = "Tweets"
+		if len(tweets) > 0 {
+			args.Title = fmt.Sprintf("Tweets by @%v", tweets[0].User().ScreenName())
+		}
+	}
+	if err = os.MkdirAll(filepath.Join(args.Dir, "media"), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not create %v: %v\n", args.Dir, err)
+		os.Exit(1)
+	}
+	if args.Media {
+		media = &MediaCopier{dir: args.Dir}
+	}
+
+	layout := template.Must(template.New("layout").Parse(LAYOUT_TEMPLATE))
+	pageTmpl := parseTemplate(layout, PAGE_TEMPLATE)
+	pages, index, entries = paginate(tweets, args.PerPage)
+	for _, page := range pages {
+		page.Archive = args.Title
+		page.Title = fmt.Sprintf("%v - %v", page.Heading, args.Title)
+		for _, tweet := range tweets[page.first:page.last] {
+			page.Tweets = append(page.Tweets, view(tweet, media))
+		}
+		if err = writeTemplate(args.Dir, page.Href, pageTmpl, page); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		index.Archive = args.Title
+		index.Title = args.Title
+		err = writeTemplate(args.Dir, "index.html", parseTemplate(layout, INDEX_TEMPLATE), index)
+	}
+	if err == nil {
+		search := &Search{Archive: args.Title, Title: "Search - " + args.Title, Limit: SEARCHLIMIT}
+		err = writeTemplate(args.Dir, "search.html", parseTemplate(layout, SEARCH_TEMPLATE), search)
+	}
+	if err == nil {
+		if entries == nil {
+			entries = []*SearchEntry{}
+		}
+		if data, err = json.Marshal(entries); err == nil {
+			err = ioutil.WriteFile(filepath.Join(args.Dir, "search.json"), data, 0644)
+		}
+	}
+	if err == nil {
+		err = ioutil.WriteFile(filepath.Join(args.Dir, "style.css"), []byte(STYLESHEET), 0644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not write the site: %v\n", err)
+		os.Exit(1)
+	}
+	if media != nil {
+		fmt.Printf("Copied %v media files, %v failed.\n", media.copied, media.failed)
+	}
+	fmt.Printf("--------------------------------------------------------\n")
+	fmt.Printf("Wrote %v Tweets in %v pages to %v\n", len(tweets), len(pages), args.Dir)
+}
@@ -0,0 +1,130 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+const STYLESHEET = `body { font-family: sans-serif; max-width: 40em; margin: 0 auto; padding: 1em; color: #14171a; }
+a { color: #1b95e0; text-decoration: none; }
+a:hover { text-decoration: underline; }
+nav { margin: 1em 0; }
+nav a { margin-right: 1em; }
+article { border-bottom: 1px solid #e6ecf0; padding: 1em 0; }
+.name { font-weight: bold; }
+.meta { color: #657786; font-size: 0.9em; }
+.text { margin: 0.5em 0; line-height: 1.4; }
+.media img, .media video { max-width: 100%; margin-top: 0.5em; border-radius: 4px; }
+.months li { margin: 0.2em 0; }
+#query { width: 100%; font-size: 1.2em; }
+`
+
+const LAYOUT_TEMPLATE = `{{define "header"}}<!DOCTYPE html>
+<html>
+  <head>
+    <meta charset="utf-8">
+    <title>{{.Title}}</title>
+    <link rel="stylesheet" href="style.css">
+  </head>
+  <body>
+    <h1><a href="index.html">{{.Archive}}</a></h1>
+    <nav><a href="index.html">Months</a><a href="search.html">Search</a></nav>
+{{end}}
+{{define "footer"}}
+  </body>
+</html>
+{{end}}
+{{define "tweet"}}
+    <article id="t{{.Id}}">
+      {{if .RetweetedBy}}<div class="meta">Retweeted by @{{.RetweetedBy}}</div>{{end}}
+      <div><span class="name">{{.Name}}</span> <span class="meta">@{{.ScreenName}}</span></div>
+      <div class="text">{{.HTML}}</div>
+      {{if .Media}}<div class="media">
+        {{range .Media}}{{if .Video}}<video controls {{if eq .Type "animated_gif"}}autoplay loop muted {{end}}poster="{{.Image}}" src="{{.Video}}"></video>
+        {{else}}<a href="{{.Image}}"><img src="{{.Image}}" alt="{{.Alt}}"></a>
+        {{end}}{{end}}
+      </div>{{end}}
+      <div class="meta"><a href="{{.Permalink}}">{{.Time}}</a></div>
+    </article>
+{{end}}`
+
+const INDEX_TEMPLATE = `{{template "header" .}}
+    <p>{{.Total}} Tweets.</p>
+    {{range .Years}}
+    <h2>{{.Year}}</h2>
+    <ul class="months">
+      {{range .Months}}<li><a href="{{.Href}}">{{.Name}}</a> ({{.Count}})</li>
+      {{end}}
+    </ul>
+    {{else}}
+    <p>No Tweets in the archive!</p>
+    {{end}}
+{{template "footer" .}}`
+
+const PAGE_TEMPLATE = `{{template "header" .}}
+    <h2>{{.Heading}}</h2>
+    {{template "pager" .}}
+    {{range .Tweets}}{{template "tweet" .}}{{end}}
+    {{template "pager" .}}
+{{template "footer" .}}
+{{define "pager"}}<nav>{{if .Newer}}<a href="{{.Newer}}">&larr; Newer</a>{{end}}{{if .Older}}<a href="{{.Older}}">Older &rarr;</a>{{end}}</nav>{{end}}`
+
+const SEARCH_TEMPLATE = `{{template "header" .}}
+    <input id="query" type="search" placeholder="Search Tweets" autofocus>
+    <p id="status" class="meta">Loading the index&hellip;</p>
+    <div id="results"></div>
+    <script>
+    (function() {
+      var index = [];
+      var query = document.getElementById("query");
+      var status = document.getElementById("status");
+      var results = document.getElementById("results");
+      function search() {
+        var words = query.value.toLowerCase().split(/\s+/).filter(Boolean);
+        results.innerHTML = "";
+        if (words.length == 0) {
+          status.textContent = index.length + " Tweets indexed.";
+          return;
+        }
+        var found = index.filter(function(entry) {
+          var text = entry.text.toLowerCase() + " @" + entry.user.toLowerCase();
+          return words.every(function(word) { return text.indexOf(word) >= 0; });
+        });
+        status.textContent = found.length + " matching Tweets.";
+        found.slice(0, {{.Limit}}).forEach(function(entry) {
+          var article = document.createElement("article");
+          var meta = document.createElement("div");
+          var link = document.createElement("a");
+          var text = document.createElement("div");
+          meta.className = "meta";
+          link.href = entry.page + "#t" + entry.id;
+          link.textContent = "@" + entry.user + ", " + entry.date;
+          text.className = "text";
+          text.textContent = entry.text;
+          meta.appendChild(link);
+          article.appendChild(text);
+          article.appendChild(meta);
+          results.appendChild(article);
+        });
+      }
+      fetch("search.json").then(function(resp) {
+        return resp.json();
+      }).then(function(data) {
+        index = data;
+        query.addEventListener("input", search);
+        search();
+      }).catch(function(err) {
+        status.textContent = "Could not load search.json, serve the archive over HTTP to search it.";
+      });
+    })();
+    </script>
+{{template "footer" .}}`
@@ -0,0 +1,201 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Builds a searchable local index of downloaded Tweets.
+package main
+
+// The index lives in the -dir directory and needs no credentials or other
+// services.  Add the archives written by user_timeline, favorites,
+// tweet_hydrate or the list exports to it with ingest.  Tweets already in
+// the index are skipped, so archives can be ingested again as they grow:
+//   $ go run ./archive_index ingest user_timeline.json favorites.json
+//   Indexed 3036 new Tweets from user_timeline.json, skipped 0.
+//   Indexed 412 new Tweets from favorites.json, skipped 17.
+//
+// Then search it with query.  Every word must appear in the Tweet, and words
+// starting with - must not.  Results can be narrowed with -user, -hashtag,
+// -lang, -since and -until (dates as YYYY-MM-DD, until is exclusive):
+//   $ go run ./archive_index -user=kurrik -since=2015-01-01 query golang -java
+//
+// The stats command summarizes what the index holds.
+
+import (
+	"flag"
+	"fmt"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/archive"
+	"github.com/kurrik/twittergo-examples/output"
+	"os"
+	"strings"
+	"time"
+)
+
+const DATEFORMAT = "2006-01-02"
+
+type Args struct {
+	Command  string
+	Dir      string
+	User     string
+	Hashtag  string
+	Lang     string
+	Since    string
+	Until    string
+	Count    int
+	Output   string
+	Template string
+	Inputs   []string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Dir, "dir", "archive_index", "Directory holding the index")
+	flag.StringVar(&a.User, "user", "", "Only Tweets by this screen name")
+	flag.StringVar(&a.Hashtag, "hashtag", "", "Only Tweets with this hashtag")
+	flag.StringVar(&a.Lang, "lang", "", "Only Tweets in this language")
+	flag.StringVar(&a.Since, "since", "", "Only Tweets from this date on, YYYY-MM-DD")
+	flag.StringVar(&a.Until, "until", "", "Only Tweets before this date, YYYY-MM-DD")
+	flag.IntVar(&a.Count, "count", 20, "Maximum number of results, 0 for all")
+	flag.StringVar(&a.Output, "output", output.Text, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
+	flag.Parse()
+	a.Command = flag.Arg(0)
+	if flag.NArg() > 1 {
+		a.Inputs = flag.Args()[1:]
+	}
+	return a
+}
+
+func ingest(ix *archive.Index, args *Args) (err error) {
+	if len(args.Inputs) == 0 {
+		return fmt.Errorf("Pass the archives to ingest after the command")
+	}
+	for _, path := range args.Inputs {
+		added, skipped := 0, 0
+		err = archive.ReadFile(path, func(tweet twittergo.Tweet) (err error) {
+			var ok bool
+			if ok, err = ix.Add(tweet); ok {
+				added++
+			} else if err == nil {
+				skipped++
+			}
+			return
+		})
+		if err != nil {
+			return
+		}
+		// Save after each file so an interrupted ingest keeps its progress.
+		if err = ix.Save(); err != nil {
+			return fmt.Errorf("Could not save index: %v", err)
+		}
+		fmt.Printf("Indexed %v new Tweets from %v, skipped %v.\n", added, path, skipped)
+	}
+	fmt.Printf("--------------------------------------------------------\n")
+	fmt.Printf("%v Tweets in %v\n", ix.Len(), args.Dir)
+	return
+}
+
+func parseDate(value string) (t time.Time, err error) {
+	if value == "" {
+		return
+	}
+	if t, err = time.Parse(DATEFORMAT, value); err != nil {
+		err = fmt.Errorf("Dates must look like %v, got %v", DATEFORMAT, value)
+	}
+	return
+}
+
+func query(ix *archive.Index, args *Args) (err error) {
+	var (
+		out    *output.Writer
+		tweet  twittergo.Tweet
+		q      *archive.Query
+		result []uint32
+	)
+	q = &archive.Query{
+		User:    args.User,
+		Hashtag: args.Hashtag,
+		Lang:    args.Lang,
+		Limit:   args.Count,
+	}
+	for _, word := range args.Inputs {
+		if strings.HasPrefix(word, "-") {
+			q.Exclude = append(q.Exclude, word[1:])
+		} else {
+			q.Terms = append(q.Terms, word)
+		}
+	}
+	if q.Since, err = parseDate(args.Since); err != nil {
+		return
+	}
+	if q.Until, err = parseDate(args.Until); err != nil {
+		return
+	}
+	if out, err = output.NewWriter(os.Stdout, args.Output, args.Template, output.TweetSchema); err != nil {
+		return
+	}
+	result = ix.Search(q)
+	for _, n := range result {
+		if tweet, err = ix.Tweet(n); err != nil {
+			out.Close()
+			return fmt.Errorf("Could not read Tweet: %v", err)
+		}
+		if err = out.Write(tweet); err != nil {
+			out.Close()
+			return
+		}
+	}
+	out.Notef("%v results.\n", len(result))
+	return out.Close()
+}
+
+func stats(ix *archive.Index, args *Args) (err error) {
+	oldest, newest := ix.Span()
+	fmt.Printf("Index:   %v\n", args.Dir)
+	fmt.Printf("Tweets:  %v\n", ix.Len())
+	fmt.Printf("Authors: %v\n", ix.Users())
+	if ix.Len() > 0 {
+		fmt.Printf("Oldest:  %v\n", oldest.Format(time.RFC1123))
+		fmt.Printf("Newest:  %v\n", newest.Format(time.RFC1123))
+	}
+	return
+}
+
+func main() {
+	var (
+		err  error
+		args *Args
+		ix   *archive.Index
+	)
+	args = parseArgs()
+	if ix, err = archive.Open(args.Dir); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not open index %v: %v\n", args.Dir, err)
+		os.Exit(1)
+	}
+	defer ix.Close()
+	switch args.Command {
+	case "ingest":
+		err = ingest(ix, args)
+	case "query":
+		err = query(ix, args)
+	case "stats", "":
+		err = stats(ix, args)
+	default:
+		err = fmt.Errorf("Unknown command: %v", args.Command)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		ix.Close()
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/directmessages"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Returns the length of the whole lines at the start of file, and how many
+// there are.  A line cut short by an interrupted export is left out.
+func completeLines(file *os.File) (size int64, count int, err error) {
+	var line string
+	reader := bufio.NewReader(file)
+	for {
+		if line, err = reader.ReadString('\n'); err == io.EOF {
+			return size, count, nil
+		} else if err != nil {
+			return
+		}
+		size += int64(len(line))
+		count++
+	}
+}
+
+// Opens the export file, keeping what an earlier run wrote when resuming.
+// Returns the cursor to continue from and the number of events already
+// written.
+func openExport(args *Args, checkpoint string) (file *os.File, cursor string, count int, err error) {
+	var (
+		data    []byte
+		size    int64
+		written = int64(-1)
+	)
+	flags := os.O_RDWR | os.O_CREATE | os.O_TRUNC
+	if args.Resume {
+		if data, err = ioutil.ReadFile(checkpoint); err != nil {
+			return nil, "", 0, fmt.Errorf("Nothing to resume, %v: %v", checkpoint, err)
+		}
+		lines := strings.SplitN(string(data), "\n", 2)
+		if cursor = strings.TrimSpace(lines[0]); cursor != "" {
+			// Without a cursor the first page was not done, so start over.
+			flags &^= os.O_TRUNC
+			if len(lines) == 2 {
+				if written, err = strconv.ParseInt(strings.TrimSpace(lines[1]), 10, 64); err != nil {
+					return nil, "", 0, fmt.Errorf("Could not read %v: %v", checkpoint, err)
+				}
+			}
+		}
+	}
+	if file, err = os.OpenFile(args.OutputFile, flags, 0644); err != nil {
+		return
+	}
+	if written >= 0 {
+		// Events written after the checkpoint are fetched again with the
+		// page they came from.
+		if err = file.Truncate(written); err != nil {
+			file.Close()
+			return
+		}
+	}
+	if size, count, err = completeLines(file); err == nil {
+		if err = file.Truncate(size); err == nil {
+			_, err = file.Seek(size, io.SeekStart)
+		}
+	}
+	if err != nil {
+		file.Close()
+	}
+	return
+}
+
+// Saves the cursor of the next page and the size of the export up to it,
+// replacing the checkpoint at once so an interruption never leaves half of
+// one.
+func saveCursor(checkpoint string, cursor string, size int64) (err error) {
+	tmp := checkpoint + ".tmp"
+	if err = ioutil.WriteFile(tmp, []byte(fmt.Sprintf("%v\n%v\n", cursor, size)), 0644); err != nil {
+		return
+	}
+	return os.Rename(tmp, checkpoint)
+}
+
+// Writes every event the API returns to args.OutputFile as NDJSON.
+func export(ctx context.Context, client *twittergo.Client, args *Args) (err error) {
+	var (
+		file   *os.File
+		page   directmessages.EventList
+		cursor string
+		count  int
+		line   []byte
+		size   int64
+	)
+	checkpoint := args.OutputFile + ".cursor"
+	if file, cursor, count, err = openExport(args, checkpoint); err != nil {
+		return
+	}
+	defer file.Close()
+	// Until the first page is done, an empty checkpoint marks the export as
+	// unfinished for -resume.
+	if cursor != "" {
+		fmt.Printf("Resuming after %v messages, from cursor %v.\n", count, cursor)
+	} else if err = saveCursor(checkpoint, "", 0); err != nil {
+		return
+	}
+	notef := func(format string, a ...interface{}) {
+		fmt.Printf(format, a...)
+	}
+	for {
+		if page, err = fetch(ctx, client, directmessages.MAXCOUNT, cursor, notef); err != nil {
+			return fmt.Errorf("Could not list messages: %v\nRun again with -resume to continue.", err)
+		}
+		for _, event := range page.Events() {
+			if line, err = json.Marshal(event); err != nil {
+				return
+			}
+			if _, err = file.Write(append(line, '\n')); err != nil {
+				return fmt.Errorf("Could not write message: %v", err)
+			}
+			count++
+		}
+		if cursor = page.NextCursor(); cursor == "" {
+			break
+		}
+		if size, err = file.Seek(0, io.SeekCurrent); err != nil {
+			return
+		}
+		if err = saveCursor(checkpoint, cursor, size); err != nil {
+			return
+		}
+	}
+	os.Remove(checkpoint)
+	fmt.Printf("--------------------------------------------------------\n")
+	fmt.Printf("Wrote %v messages to %v\n", count, args.OutputFile)
+	return
+}
@@ -0,0 +1,275 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Sends, lists and exports direct messages.
+package main
+
+// Send a message to -to, a screen name, or -recipient_id, with an optional
+// photo, GIF or video in -media and up to 20 quick reply options.  Each
+// -option is a label, optionally followed by a description and metadata
+// after | characters:
+//   $ go run ./direct_messages -to=kurrik -text="Lunch today?" \
+//       -option="Yes|Sounds good|lunch-yes" -option="No" send
+//   Sent:                 1054476893592154117
+//
+// List the most recent messages, a page of -count at a time.  Pass the
+// cursor printed at the end to -cursor for the next page, or ask for more
+// pages with -pages.  Use -output to print json, ndjson, csv or tsv:
+//   $ go run ./direct_messages -count=20 list
+//
+// Export every message the API still has, which covers the last 30 days,
+// to an NDJSON file with one event per line.  The cursor and the size of
+// the file so far are kept in <out>.cursor after every page, so an
+// interrupted export resumes when run again with -resume, without
+// repeating the events of the page it stopped in:
+//   $ go run ./direct_messages -out=direct_messages.json export
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/kurrik/oauth1a"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/directmessages"
+	"github.com/kurrik/twittergo-examples/output"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+)
+
+const MINWAIT = time.Duration(10) * time.Second
+
+func LoadCredentials() (client *twittergo.Client, err error) {
+	credentials, err := ioutil.ReadFile("CREDENTIALS")
+	if err != nil {
+		return
+	}
+	lines := strings.Split(string(credentials), "\n")
+	config := &oauth1a.ClientConfig{
+		ConsumerKey:    lines[0],
+		ConsumerSecret: lines[1],
+	}
+	user := oauth1a.NewAuthorizedConfig(lines[2], lines[3])
+	client = twittergo.NewClient(config, user)
+	return
+}
+
+// A flag which may be given several times.
+type optionFlag struct {
+	options *[]directmessages.QuickReply
+}
+
+func (f optionFlag) String() string {
+	return ""
+}
+
+// Parses label|description|metadata.
+func (f optionFlag) Set(value string) error {
+	parts := strings.SplitN(value, "|", 3)
+	option := directmessages.QuickReply{Label: strings.TrimSpace(parts[0])}
+	if len(parts) > 1 {
+		option.Description = strings.TrimSpace(parts[1])
+	}
+	if len(parts) > 2 {
+		option.Metadata = parts[2]
+	}
+	*f.options = append(*f.options, option)
+	return nil
+}
+
+type Args struct {
+	Command     string
+	To          string
+	RecipientId string
+	Text        string
+	Media       string
+	Options     []directmessages.QuickReply
+	Count       int
+	Cursor      string
+	Pages       int
+	OutputFile  string
+	Resume      bool
+	Output      string
+	Template    string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.To, "to", "", "Screen name to send the message to")
+	flag.StringVar(&a.RecipientId, "recipient_id", "", "User ID to send the message to, instead of -to")
+	flag.StringVar(&a.Text, "text", "", "Text of the message")
+	flag.StringVar(&a.Media, "media", "", "Photo, GIF or video to attach")
+	flag.Var(optionFlag{&a.Options}, "option", "Quick reply option as label|description|metadata, may be repeated")
+	flag.IntVar(&a.Count, "count", 20, "Messages per page, at most 50")
+	flag.StringVar(&a.Cursor, "cursor", "", "Cursor of the page to list first")
+	flag.IntVar(&a.Pages, "pages", 1, "Pages to list")
+	flag.StringVar(&a.OutputFile, "out", "direct_messages.json", "File to export to")
+	flag.BoolVar(&a.Resume, "resume", false, "Continue an interrupted export")
+	flag.StringVar(&a.Output, "output", output.Text, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
+	flag.Parse()
+	a.Command = flag.Arg(0)
+	return a
+}
+
+// Looks up the user ID of a screen name.
+func userId(client *twittergo.Client, screenName string) (id string, err error) {
+	var (
+		req  *http.Request
+		resp *twittergo.APIResponse
+	)
+	query := url.Values{}
+	query.Set("screen_name", strings.TrimPrefix(screenName, "@"))
+	if req, err = http.NewRequest("GET", fmt.Sprintf("/1.1/users/show.json?%v", query.Encode()), nil); err != nil {
+		return
+	}
+	if resp, err = client.SendRequest(req); err != nil {
+		return
+	}
+	user := &twittergo.User{}
+	if err = resp.Parse(user); err != nil {
+		return
+	}
+	return user.IdStr(), nil
+}
+
+func send(ctx context.Context, client *twittergo.Client, args *Args) (err error) {
+	var event directmessages.Event
+	m := &directmessages.Message{
+		RecipientId:  args.RecipientId,
+		Text:         args.Text,
+		QuickReplies: args.Options,
+	}
+	// Check the message before anything is looked up or uploaded, with
+	// stand-ins for the IDs those return.
+	draft := *m
+	if draft.RecipientId == "" {
+		draft.RecipientId = args.To
+	}
+	draft.MediaId = args.Media
+	if err = draft.Check(); err != nil {
+		return
+	}
+	if args.RecipientId == "" {
+		if m.RecipientId, err = userId(client, args.To); err != nil {
+			return fmt.Errorf("Could not look up %v: %v", args.To, err)
+		}
+	}
+	if args.Media != "" {
+		if m.MediaId, err = directmessages.UploadMedia(ctx, client, args.Media); err != nil {
+			return fmt.Errorf("Could not upload %v: %v", args.Media, err)
+		}
+		fmt.Printf("Media:                %v\n", m.MediaId)
+	}
+	if event, err = directmessages.Send(ctx, client, m); err != nil {
+		return fmt.Errorf("Could not send message: %v", err)
+	}
+	fmt.Printf("Sent:                 %v\n", event.Id())
+	fmt.Printf("To:                   %v\n", event.RecipientId())
+	fmt.Printf("Text:                 %v\n", event.Text())
+	return
+}
+
+// Fetches a page, waiting out rate limits.
+func fetch(ctx context.Context, client *twittergo.Client, count int, cursor string, notef func(string, ...interface{})) (page directmessages.EventList, err error) {
+	var resp *twittergo.APIResponse
+	for {
+		page, resp, err = directmessages.List(ctx, client, count, cursor)
+		if rle, ok := err.(twittergo.RateLimitError); ok {
+			dur := rle.Reset.Sub(time.Now()) + time.Second
+			if dur < MINWAIT {
+				dur = MINWAIT
+			}
+			notef("Rate limited. Reset at %v. Waiting for %v\n", rle.Reset, dur)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(dur):
+			}
+			continue
+		}
+		if err == nil && resp.HasRateLimit() {
+			notef("Got %v messages, %v calls available.\n", len(page.Events()), resp.RateLimitRemaining())
+		}
+		return
+	}
+}
+
+func list(ctx context.Context, client *twittergo.Client, args *Args) (err error) {
+	var (
+		out  *output.Writer
+		page directmessages.EventList
+	)
+	if out, err = output.NewWriter(os.Stdout, args.Output, args.Template, directmessages.Schema); err != nil {
+		return
+	}
+	cursor := args.Cursor
+	for i := 0; i < args.Pages; i++ {
+		if page, err = fetch(ctx, client, args.Count, cursor, out.Notef); err != nil {
+			out.Close()
+			return fmt.Errorf("Could not list messages: %v", err)
+		}
+		for _, event := range page.Events() {
+			if err = out.Write(event); err != nil {
+				out.Close()
+				return
+			}
+		}
+		if cursor = page.NextCursor(); cursor == "" {
+			break
+		}
+	}
+	if cursor != "" {
+		out.Notef("More with -cursor=%v\n", cursor)
+	}
+	return out.Close()
+}
+
+func main() {
+	var (
+		err    error
+		client *twittergo.Client
+		args   *Args
+	)
+	args = parseArgs()
+	if args.Count < 1 || args.Count > directmessages.MAXCOUNT {
+		fmt.Printf("-count must be between 1 and %v.\n", directmessages.MAXCOUNT)
+		os.Exit(1)
+	}
+	if client, err = LoadCredentials(); err != nil {
+		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+		os.Exit(1)
+	}
+	// Ctrl-C stops uploads and waits for rate limits.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	switch args.Command {
+	case "send":
+		err = send(ctx, client, args)
+	case "list":
+		err = list(ctx, client, args)
+	case "export":
+		err = export(ctx, client, args)
+	default:
+		err = fmt.Errorf("Unknown command %v, use send, list or export", args.Command)
+	}
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,212 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Sends and lists direct messages with the direct_messages/events
+// endpoints, which take and return JSON:
+//
+//	event, err := directmessages.Send(ctx, client, &directmessages.Message{
+//	    RecipientId: "7588892",
+//	    Text:        "Lunch today?",
+//	    QuickReplies: []directmessages.QuickReply{
+//	        {Label: "Yes"}, {Label: "No"},
+//	    },
+//	})
+//
+// Media is uploaded with UploadMedia, in the dm_ categories, and attached
+// with Message.MediaId.
+package directmessages
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"unicode/utf8"
+
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/mediaupload"
+)
+
+// Limits of direct messages and their quick replies, in characters.
+const (
+	MAXTEXT        = 10000
+	MAXOPTIONS     = 20
+	MAXLABEL       = 36
+	MAXDESCRIPTION = 72
+	MAXMETADATA    = 1000
+)
+
+// Most events direct_messages/events/list.json returns per page.
+const MAXCOUNT = 50
+
+// An option offered with a message.  The recipient picks one by tapping
+// it, which sends the label back with the metadata.
+type QuickReply struct {
+	Label       string `json:"label"`
+	Description string `json:"description,omitempty"`
+	Metadata    string `json:"metadata,omitempty"`
+}
+
+// A direct message to send.
+type Message struct {
+	// User ID of the recipient.
+	RecipientId string
+	Text        string
+	// Media uploaded with UploadMedia.
+	MediaId      string
+	QuickReplies []QuickReply
+}
+
+func tooLong(what string, text string, max int) error {
+	if n := utf8.RuneCountInString(text); n > max {
+		return fmt.Errorf("%v is %v characters, at most %v are allowed", what, n, max)
+	}
+	return nil
+}
+
+// Checks the message against the limits of the API.
+func (m *Message) Check() (err error) {
+	if m.RecipientId == "" {
+		return fmt.Errorf("No recipient")
+	}
+	if m.Text == "" && m.MediaId == "" {
+		return fmt.Errorf("The message needs text or media")
+	}
+	if err = tooLong("The message", m.Text, MAXTEXT); err != nil {
+		return
+	}
+	if len(m.QuickReplies) > MAXOPTIONS {
+		return fmt.Errorf("%v quick reply options, at most %v are allowed", len(m.QuickReplies), MAXOPTIONS)
+	}
+	for i, option := range m.QuickReplies {
+		if option.Label == "" {
+			return fmt.Errorf("Quick reply option %v has no label", i+1)
+		}
+		if err = tooLong(fmt.Sprintf("Label of option %v", i+1), option.Label, MAXLABEL); err != nil {
+			return
+		}
+		if err = tooLong(fmt.Sprintf("Description of option %v", i+1), option.Description, MAXDESCRIPTION); err != nil {
+			return
+		}
+		if err = tooLong(fmt.Sprintf("Metadata of option %v", i+1), option.Metadata, MAXMETADATA); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// Returns the message as the body of direct_messages/events/new.json.
+func (m *Message) Body() (body []byte, err error) {
+	if err = m.Check(); err != nil {
+		return
+	}
+	data := map[string]interface{}{"text": m.Text}
+	if m.MediaId != "" {
+		data["attachment"] = map[string]interface{}{
+			"type":  "media",
+			"media": map[string]string{"id": m.MediaId},
+		}
+	}
+	if len(m.QuickReplies) > 0 {
+		data["quick_reply"] = map[string]interface{}{
+			"type":    "options",
+			"options": m.QuickReplies,
+		}
+	}
+	return json.Marshal(map[string]interface{}{
+		"event": map[string]interface{}{
+			"type": MESSAGECREATE,
+			MESSAGECREATE: map[string]interface{}{
+				"target":       map[string]string{"recipient_id": m.RecipientId},
+				"message_data": data,
+			},
+		},
+	})
+}
+
+// Sends m with direct_messages/events/new.json, returning the event
+// created.
+func Send(ctx context.Context, client *twittergo.Client, m *Message) (event Event, err error) {
+	var (
+		body []byte
+		req  *http.Request
+		resp *twittergo.APIResponse
+	)
+	if body, err = m.Body(); err != nil {
+		return
+	}
+	if req, err = http.NewRequestWithContext(ctx, "POST", "/1.1/direct_messages/events/new.json", bytes.NewReader(body)); err != nil {
+		return nil, fmt.Errorf("Could not parse request: %v", err)
+	}
+	// JSON bodies are not part of the OAuth signature.
+	req.Header.Set("Content-Type", "application/json")
+	if resp, err = client.SendRequest(req); err != nil {
+		return nil, fmt.Errorf("Could not send request: %v", err)
+	}
+	result := map[string]interface{}{}
+	if err = resp.Parse(&result); err != nil {
+		return
+	}
+	e, _ := result["event"].(map[string]interface{})
+	return Event(e), nil
+}
+
+// Fetches a page of the direct messages sent and received in the last 30
+// days, newest first, with direct_messages/events/list.json.  Pass the
+// NextCursor of a page to get the one after it.
+func List(ctx context.Context, client *twittergo.Client, count int, cursor string) (page EventList, resp *twittergo.APIResponse, err error) {
+	var req *http.Request
+	query := url.Values{}
+	if count > 0 {
+		query.Set("count", fmt.Sprintf("%v", count))
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	path := fmt.Sprintf("/1.1/direct_messages/events/list.json?%v", query.Encode())
+	if req, err = http.NewRequestWithContext(ctx, "GET", path, nil); err != nil {
+		return nil, nil, fmt.Errorf("Could not parse request: %v", err)
+	}
+	if resp, err = client.SendRequest(req); err != nil {
+		return nil, nil, fmt.Errorf("Could not send request: %v", err)
+	}
+	page = EventList{}
+	if err = resp.Parse(&page); err != nil {
+		return nil, resp, err
+	}
+	return
+}
+
+// Uploads the photo, GIF or video at path for a direct message, returning
+// its media ID.
+func UploadMedia(ctx context.Context, client *twittergo.Client, path string) (mediaId string, err error) {
+	var (
+		media     *mediaupload.Media
+		mediaResp twittergo.MediaResponse
+	)
+	if media, err = mediaupload.Detect(path); err != nil {
+		return
+	}
+	upload := &mediaupload.Upload{
+		Path:      path,
+		MediaType: media.MediaType,
+		Category:  mediaupload.DirectMessageCategories[media.Category],
+	}
+	if mediaResp, err = upload.Run(ctx, client); err != nil {
+		return
+	}
+	return mediaupload.MediaId(mediaResp), nil
+}
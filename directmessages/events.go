@@ -0,0 +1,166 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package directmessages
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/kurrik/twittergo-examples/output"
+)
+
+// Type of the events which hold a message.
+const MESSAGECREATE = "message_create"
+
+// A direct message event, as returned by the direct_messages/events
+// endpoints.  Like twittergo.Tweet it keeps the JSON as is, so nothing is
+// lost when events are exported.
+type Event map[string]interface{}
+
+func (e Event) Id() string {
+	return output.Value(e, "id")
+}
+
+// MESSAGECREATE for messages.
+func (e Event) Type() string {
+	return output.Value(e, "type")
+}
+
+// Returns when the event happened, or the zero time if unknown.
+func (e Event) CreatedAt() time.Time {
+	// Milliseconds since the epoch, as a string.
+	ms, err := strconv.ParseInt(output.Value(e, "created_timestamp"), 10, 64)
+	if err != nil {
+		return time.Time{}
+	}
+	return time.Unix(0, ms*int64(time.Millisecond))
+}
+
+func (e Event) messageCreate() map[string]interface{} {
+	return output.Object(e, MESSAGECREATE)
+}
+
+func (e Event) messageData() map[string]interface{} {
+	return output.Object(e.messageCreate(), "message_data")
+}
+
+// User ID of the sender.
+func (e Event) SenderId() string {
+	return output.Value(e.messageCreate(), "sender_id")
+}
+
+// User ID of the recipient.
+func (e Event) RecipientId() string {
+	return output.Value(output.Object(e.messageCreate(), "target"), "recipient_id")
+}
+
+func (e Event) Text() string {
+	return output.Value(e.messageData(), "text")
+}
+
+// Returns the URL of the attached photo, GIF or video, if there is one.
+// Fetching it needs the credentials of the sender or recipient.
+func (e Event) MediaUrl() string {
+	media := output.Object(output.Object(e.messageData(), "attachment"), "media")
+	return output.Value(media, "media_url_https")
+}
+
+// Returns the metadata of the quick reply option the sender picked, if the
+// message answers one.
+func (e Event) QuickReplyResponse() string {
+	return output.Value(output.Object(e.messageData(), "quick_reply_response"), "metadata")
+}
+
+// Labels of the quick reply options offered with the message.
+func (e Event) QuickReplyOptions() (labels []string) {
+	for _, option := range output.Array(output.Object(e.messageData(), "quick_reply"), "options") {
+		if o, ok := option.(map[string]interface{}); ok {
+			labels = append(labels, output.Value(o, "label"))
+		}
+	}
+	return
+}
+
+// A page of direct_messages/events/list.json.
+type EventList map[string]interface{}
+
+func (l EventList) Events() (events []Event) {
+	for _, item := range output.Array(l, "events") {
+		if e, ok := item.(map[string]interface{}); ok {
+			events = append(events, Event(e))
+		}
+	}
+	return
+}
+
+// Cursor of the next page, blank on the last one.
+func (l EventList) NextCursor() string {
+	return output.Value(l, "next_cursor")
+}
+
+func asEvent(record interface{}) Event {
+	if e, ok := record.(Event); ok {
+		return e
+	}
+	return Event{}
+}
+
+func createdAt(e Event) string {
+	if t := e.CreatedAt(); !t.IsZero() {
+		return t.UTC().Format(time.RFC3339)
+	}
+	return ""
+}
+
+func eventColumn(name string, value func(Event) string) output.Column {
+	return output.Column{
+		Name: name,
+		Value: func(record interface{}) string {
+			return value(asEvent(record))
+		},
+	}
+}
+
+// Writes Event records.
+var Schema = &output.Schema{
+	Columns: []output.Column{
+		eventColumn("id", Event.Id),
+		eventColumn("created_at", createdAt),
+		eventColumn("type", Event.Type),
+		eventColumn("sender_id", Event.SenderId),
+		eventColumn("recipient_id", Event.RecipientId),
+		eventColumn("text", Event.Text),
+		eventColumn("media_url", Event.MediaUrl),
+		eventColumn("quick_reply_response", Event.QuickReplyResponse),
+	},
+	Text: func(w io.Writer, i int, record interface{}) (err error) {
+		e := asEvent(record)
+		if _, err = fmt.Fprintf(w, "%v.) %v -> %v at %v\n    %v\n", i, e.SenderId(), e.RecipientId(),
+			createdAt(e), e.Text()); err != nil {
+			return
+		}
+		if url := e.MediaUrl(); url != "" {
+			if _, err = fmt.Fprintf(w, "    Media: %v\n", url); err != nil {
+				return
+			}
+		}
+		if options := e.QuickReplyOptions(); len(options) > 0 {
+			_, err = fmt.Fprintf(w, "    Options: %v\n", options)
+		}
+		return
+	},
+}
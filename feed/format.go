@@ -0,0 +1,230 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/render"
+	"html/template"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	ATOM = "atom"
+	RSS  = "rss"
+	// Longest entry title, in characters.
+	TITLELENGTH = 100
+)
+
+type AtomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type AtomPerson struct {
+	Name string `xml:"name"`
+	URI  string `xml:"uri,omitempty"`
+}
+
+type AtomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type AtomEntry struct {
+	Id        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Author    AtomPerson  `xml:"author"`
+	Link      AtomLink    `xml:"link"`
+	Content   AtomContent `xml:"content"`
+}
+
+type AtomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Id      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []AtomLink  `xml:"link"`
+	Entries []AtomEntry `xml:"entry"`
+}
+
+type RSSGuid struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type RSSItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link"`
+	Guid        RSSGuid `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Description string  `xml:"description"`
+}
+
+type RSSChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []RSSItem `xml:"item"`
+}
+
+type RSSFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel RSSChannel `xml:"channel"`
+}
+
+// A Tweet prepared for either kind of feed.
+type Entry struct {
+	Id        string
+	Title     string
+	Author    string
+	AuthorURI string
+	Link      string
+	Published time.Time
+	HTML      string
+}
+
+// Stable across renames of the author, unlike the permalink.
+func entryId(tweet twittergo.Tweet) string {
+	return fmt.Sprintf("tag:twitter.com,2006:status/%v", tweet.IdStr())
+}
+
+func entryTitle(author string, text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > TITLELENGTH {
+		text = string(runes[:TITLELENGTH-1]) + "…"
+	}
+	return fmt.Sprintf("@%v: %v", author, text)
+}
+
+// Builds the HTML content of an entry from the text and media of a Tweet.
+func entryHTML(tweet twittergo.Tweet) string {
+	var out strings.Builder
+	out.WriteString("<p>")
+	out.WriteString(string(render.HTML(tweet)))
+	out.WriteString("</p>")
+	for _, m := range render.AllMedia(tweet) {
+		img := fmt.Sprintf(`<img src="%v" alt="%v">`,
+			template.HTMLEscapeString(m.Image), template.HTMLEscapeString(m.Alt))
+		if m.Video != "" {
+			fmt.Fprintf(&out, `<p><a href="%v">%v</a></p>`, template.HTMLEscapeString(m.Video), img)
+		} else {
+			fmt.Fprintf(&out, "<p>%v</p>", img)
+		}
+	}
+	return out.String()
+}
+
+func NewEntry(tweet twittergo.Tweet) *Entry {
+	original := tweet
+	e := &Entry{
+		Id:        entryId(tweet),
+		Author:    tweet.User().ScreenName(),
+		Published: tweet.CreatedAt(),
+	}
+	e.AuthorURI = "https://twitter.com/" + e.Author
+	if rt, ok := tweet["retweeted_status"].(map[string]interface{}); ok {
+		// Retweets are truncated, so show the original Tweet in full.
+		original = twittergo.Tweet(rt)
+		name := original.User().ScreenName()
+		e.Title = entryTitle(e.Author, fmt.Sprintf("RT @%v: %v", name, render.Text(original)))
+		e.HTML = fmt.Sprintf(`<p>Retweeted <a href="https://twitter.com/%v">@%v</a>:</p>%v`,
+			template.HTMLEscapeString(name), template.HTMLEscapeString(name), entryHTML(original))
+	} else {
+		e.Title = entryTitle(e.Author, render.Text(tweet))
+		e.HTML = entryHTML(tweet)
+	}
+	e.Link = render.Permalink(original)
+	return e
+}
+
+// Writes entries, newest first, as an Atom or RSS 2.0 feed.  self is the
+// address the feed is served from, if known.
+func WriteFeed(w io.Writer, format string, src *Source, self string, entries []*Entry, updated time.Time) (err error) {
+	var doc interface{}
+	switch format {
+	case ATOM:
+		feed := &AtomFeed{
+			Id:      src.Link,
+			Title:   src.Title,
+			Updated: updated.UTC().Format(time.RFC3339),
+			Links:   []AtomLink{{Rel: "alternate", Type: "text/html", Href: src.Link}},
+		}
+		if self != "" {
+			feed.Links = append(feed.Links, AtomLink{Rel: "self", Type: "application/atom+xml", Href: self})
+		}
+		for _, e := range entries {
+			feed.Entries = append(feed.Entries, AtomEntry{
+				Id:        e.Id,
+				Title:     e.Title,
+				Updated:   e.Published.UTC().Format(time.RFC3339),
+				Published: e.Published.UTC().Format(time.RFC3339),
+				Author:    AtomPerson{Name: e.Author, URI: e.AuthorURI},
+				Link:      AtomLink{Rel: "alternate", Type: "text/html", Href: e.Link},
+				Content:   AtomContent{Type: "html", Body: e.HTML},
+			})
+		}
+		doc = feed
+	case RSS:
+		feed := &RSSFeed{
+			Version: "2.0",
+			Channel: RSSChannel{
+				Title:         src.Title,
+				Link:          src.Link,
+				Description:   src.Title,
+				LastBuildDate: updated.UTC().Format(time.RFC1123Z),
+			},
+		}
+		for _, e := range entries {
+			feed.Channel.Items = append(feed.Channel.Items, RSSItem{
+				Title:       e.Title,
+				Link:        e.Link,
+				Guid:        RSSGuid{IsPermaLink: false, Value: e.Id},
+				PubDate:     e.Published.UTC().Format(time.RFC1123Z),
+				Description: e.HTML,
+			})
+		}
+		doc = feed
+	default:
+		return fmt.Errorf("Unknown feed format %v, use atom or rss", format)
+	}
+	if _, err = io.WriteString(w, xml.Header); err != nil {
+		return
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err = enc.Encode(doc); err != nil {
+		return
+	}
+	_, err = io.WriteString(w, "\n")
+	return
+}
+
+// Returns the content type to serve a feed format with.
+func ContentType(format string) string {
+	if format == RSS {
+		return "application/rss+xml; charset=utf-8"
+	}
+	return "application/atom+xml; charset=utf-8"
+}
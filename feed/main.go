@@ -0,0 +1,199 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Turns user timelines, lists and searches into Atom or RSS feeds.
+package main
+
+// Writes a single feed to stdout or the -out file:
+//   $ go run ./feed -screen_name=kurrik > kurrik.atom
+//   $ go run ./feed -owner_screen_name=kurrik -slug=team -format=rss -out=team.rss
+//   $ go run ./feed -q="#golang" -count=100
+//
+// Or serves feeds for feed readers to poll, taking the same parameters from
+// the query string:
+//   $ go run ./feed -http=:8080
+//   http://localhost:8080/?screen_name=kurrik
+//   http://localhost:8080/?list_id=123&format=rss
+//   http://localhost:8080/?q=%23golang
+//
+// Fetched Tweets are cached in -cache_dir for -cache_ttl, so feeds can be
+// polled as often as readers like without using up the rate limit.  If the
+// API fails, for instance when rate limited, the last Tweets fetched are
+// served instead.  Served feeds also honor If-Modified-Since.
+
+import (
+	"flag"
+	"fmt"
+	"github.com/kurrik/oauth1a"
+	"github.com/kurrik/twittergo"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+func LoadCredentials() (client *twittergo.Client, err error) {
+	credentials, err := ioutil.ReadFile("CREDENTIALS")
+	if err != nil {
+		return
+	}
+	lines := strings.Split(string(credentials), "\n")
+	config := &oauth1a.ClientConfig{
+		ConsumerKey:    lines[0],
+		ConsumerSecret: lines[1],
+	}
+	user := oauth1a.NewAuthorizedConfig(lines[2], lines[3])
+	client = twittergo.NewClient(config, user)
+	return
+}
+
+type Args struct {
+	ScreenName      string
+	ListId          string
+	Slug            string
+	OwnerScreenName string
+	Query           string
+	Format          string
+	Count           int
+	OutputFile      string
+	Http            string
+	CacheDir        string
+	CacheTTL        time.Duration
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.ScreenName, "screen_name", "", "Feed of this user's Tweets")
+	flag.StringVar(&a.ListId, "list_id", "", "Feed of the list with this ID")
+	flag.StringVar(&a.Slug, "slug", "", "Feed of the list with this slug, with -owner_screen_name")
+	flag.StringVar(&a.OwnerScreenName, "owner_screen_name", "", "Owner of the -slug list")
+	flag.StringVar(&a.Query, "q", "", "Feed of the results of this search")
+	flag.StringVar(&a.Format, "format", ATOM, "Feed format: atom or rss")
+	flag.IntVar(&a.Count, "count", 50, "Tweets per feed")
+	flag.StringVar(&a.OutputFile, "out", "", "Output file, stdout if blank")
+	flag.StringVar(&a.Http, "http", "", "Serve feeds on this address, like :8080")
+	flag.StringVar(&a.CacheDir, "cache_dir", filepath.Join(os.TempDir(), "twittergo_feed"), "Directory to cache Tweets in")
+	flag.DurationVar(&a.CacheTTL, "cache_ttl", 5*time.Minute, "How long to serve cached Tweets")
+	flag.Parse()
+	return a
+}
+
+// Builds the feed of src.  Returns when its content last changed.
+func buildFeed(w io.Writer, client *twittergo.Client, cache *Cache, src *Source, format string, self string) (updated time.Time, err error) {
+	var (
+		tweets  []twittergo.Tweet
+		entries []*Entry
+	)
+	if tweets, updated, err = cache.Get(client, src); err != nil {
+		return
+	}
+	for _, tweet := range tweets {
+		entries = append(entries, NewEntry(tweet))
+	}
+	if len(entries) > 0 {
+		updated = entries[0].Published
+	}
+	err = WriteFeed(w, format, src, self, entries, updated)
+	return
+}
+
+type Server struct {
+	Client *twittergo.Client
+	Cache  *Cache
+	Count  int
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var (
+		src     *Source
+		err     error
+		updated time.Time
+		body    strings.Builder
+	)
+	params := r.URL.Query()
+	format := params.Get("format")
+	if format == "" {
+		format = ATOM
+	}
+	if format != ATOM && format != RSS {
+		http.Error(w, "Unknown format, use atom or rss", http.StatusBadRequest)
+		return
+	}
+	if src, err = NewSource(params, s.Count); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	self := (&url.URL{Scheme: "http", Host: r.Host, Path: r.URL.Path, RawQuery: r.URL.RawQuery}).String()
+	if updated, err = buildFeed(&body, s.Client, s.Cache, src, format, self); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not build feed for %v: %v\n", r.URL, err)
+		http.Error(w, fmt.Sprintf("Could not fetch Tweets: %v", err), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("max-age=%v", int(s.Cache.TTL.Seconds())))
+	w.Header().Set("Last-Modified", updated.UTC().Format(http.TimeFormat))
+	if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && !updated.Truncate(time.Second).After(since) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType(format))
+	io.WriteString(w, body.String())
+}
+
+func main() {
+	var (
+		err    error
+		client *twittergo.Client
+		args   *Args
+		src    *Source
+		out    *os.File
+	)
+	args = parseArgs()
+	if client, err = LoadCredentials(); err != nil {
+		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+		os.Exit(1)
+	}
+	cache := &Cache{Dir: args.CacheDir, TTL: args.CacheTTL}
+	if args.Http != "" {
+		fmt.Fprintf(os.Stderr, "Serving feeds on %v\n", args.Http)
+		err = http.ListenAndServe(args.Http, &Server{Client: client, Cache: cache, Count: args.Count})
+		fmt.Fprintf(os.Stderr, "Could not serve feeds: %v\n", err)
+		os.Exit(1)
+	}
+	params := url.Values{}
+	params.Set("screen_name", args.ScreenName)
+	params.Set("list_id", args.ListId)
+	params.Set("slug", args.Slug)
+	params.Set("owner_screen_name", args.OwnerScreenName)
+	params.Set("q", args.Query)
+	if src, err = NewSource(params, args.Count); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+	out = os.Stdout
+	if args.OutputFile != "" {
+		if out, err = os.Create(args.OutputFile); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not create output file %v: %v\n", args.OutputFile, err)
+			os.Exit(1)
+		}
+		defer out.Close()
+	}
+	if _, err = buildFeed(out, client, cache, src, args.Format, ""); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not build feed: %v\n", err)
+		os.Exit(1)
+	}
+}
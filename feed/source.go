@@ -0,0 +1,197 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/archive"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// The Tweets a feed is made from.
+type Source struct {
+	// API endpoint and query to fetch the Tweets with.
+	Path  string
+	Query url.Values
+	// Describes the feed.
+	Title string
+	Link  string
+}
+
+// Picks the source from the same parameters the API takes: screen_name for
+// a user timeline, list_id or slug and owner_screen_name for a list, or q
+// for a search.
+func NewSource(params url.Values, count int) (src *Source, err error) {
+	src = &Source{Query: url.Values{}}
+	src.Query.Set("count", fmt.Sprintf("%v", count))
+	src.Query.Set("tweet_mode", "extended")
+	switch {
+	case params.Get("screen_name") != "":
+		name := params.Get("screen_name")
+		src.Path = "/1.1/statuses/user_timeline.json"
+		src.Query.Set("screen_name", name)
+		src.Query.Set("include_rts", "true")
+		src.Title = fmt.Sprintf("Tweets by @%v", name)
+		src.Link = "https://twitter.com/" + url.PathEscape(name)
+	case params.Get("list_id") != "":
+		id := params.Get("list_id")
+		src.Path = "/1.1/lists/statuses.json"
+		src.Query.Set("list_id", id)
+		src.Query.Set("include_rts", "true")
+		src.Title = fmt.Sprintf("List %v", id)
+		src.Link = "https://twitter.com/i/lists/" + url.PathEscape(id)
+	case params.Get("slug") != "" && params.Get("owner_screen_name") != "":
+		slug, owner := params.Get("slug"), params.Get("owner_screen_name")
+		src.Path = "/1.1/lists/statuses.json"
+		src.Query.Set("slug", slug)
+		src.Query.Set("owner_screen_name", owner)
+		src.Query.Set("include_rts", "true")
+		src.Title = fmt.Sprintf("@%v/%v", owner, slug)
+		src.Link = fmt.Sprintf("https://twitter.com/%v/lists/%v", url.PathEscape(owner), url.PathEscape(slug))
+	case params.Get("q") != "":
+		q := params.Get("q")
+		src.Path = "/1.1/search/tweets.json"
+		src.Query.Set("q", q)
+		src.Query.Set("result_type", "recent")
+		src.Title = fmt.Sprintf("Search for %v", q)
+		src.Link = "https://twitter.com/search?" + url.Values{"q": {q}}.Encode()
+	default:
+		err = fmt.Errorf("Specify a screen_name, a list_id, a slug and owner_screen_name, or a q")
+	}
+	return
+}
+
+// Requests the Tweets of src, newest first.
+func (src *Source) Fetch(client *twittergo.Client) (tweets []twittergo.Tweet, err error) {
+	var (
+		req  *http.Request
+		resp *twittergo.APIResponse
+	)
+	endpoint := fmt.Sprintf("%v?%v", src.Path, src.Query.Encode())
+	if req, err = http.NewRequest("GET", endpoint, nil); err != nil {
+		return
+	}
+	if resp, err = client.SendRequest(req); err != nil {
+		return
+	}
+	if src.Path == "/1.1/search/tweets.json" {
+		results := &twittergo.SearchResults{}
+		if err = resp.Parse(results); err == nil {
+			tweets = results.Statuses()
+		}
+	} else {
+		results := &twittergo.Timeline{}
+		if err = resp.Parse(results); err == nil {
+			tweets = []twittergo.Tweet(*results)
+		}
+	}
+	if err == nil && resp.HasRateLimit() {
+		fmt.Fprintf(os.Stderr, "Fetched %v, %v calls available.\n", endpoint, resp.RateLimitRemaining())
+	}
+	return
+}
+
+// Keeps the Tweets fetched for each source in a directory, one NDJSON file
+// per source, so that polling a feed more often than every TTL does not use
+// up the rate limit.
+type Cache struct {
+	Dir string
+	TTL time.Duration
+	// Only one fetch at a time, so that concurrent requests for the same
+	// feed share a single API call.
+	lock sync.Mutex
+}
+
+func (c *Cache) path(src *Source) string {
+	key := fmt.Sprintf("%v?%v", src.Path, src.Query.Encode())
+	return filepath.Join(c.Dir, fmt.Sprintf("%x.json", sha1.Sum([]byte(key))))
+}
+
+func (c *Cache) read(path string) (tweets []twittergo.Tweet, err error) {
+	err = archive.ReadFile(path, func(tweet twittergo.Tweet) error {
+		tweets = append(tweets, tweet)
+		return nil
+	})
+	return
+}
+
+func (c *Cache) write(path string, tweets []twittergo.Tweet) (err error) {
+	var (
+		out  *os.File
+		text []byte
+	)
+	if err = os.MkdirAll(c.Dir, 0755); err != nil {
+		return
+	}
+	if out, err = os.Create(path + ".tmp"); err != nil {
+		return
+	}
+	for _, tweet := range tweets {
+		if text, err = json.Marshal(tweet); err != nil {
+			break
+		}
+		if _, err = fmt.Fprintf(out, "%s\n", text); err != nil {
+			break
+		}
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path + ".tmp")
+		return
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+// Returns the Tweets of src from the cache if they were fetched within the
+// TTL, and fetches them otherwise.  When the API fails, for instance when
+// rate limited, stale Tweets are returned instead if there are any.  Also
+// returns when the Tweets were fetched.
+func (c *Cache) Get(client *twittergo.Client, src *Source) (tweets []twittergo.Tweet, fetched time.Time, err error) {
+	var info os.FileInfo
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	path := c.path(src)
+	if info, err = os.Stat(path); err == nil {
+		fetched = info.ModTime()
+		if time.Since(fetched) < c.TTL {
+			tweets, err = c.read(path)
+			return
+		}
+	}
+	if tweets, err = src.Fetch(client); err != nil {
+		if info == nil {
+			return
+		}
+		fmt.Fprintf(os.Stderr, "Could not fetch %v, using Tweets from %v: %v\n", src.Title, fetched, err)
+		tweets, err = c.read(path)
+		return
+	}
+	fetched = time.Now()
+	if err = c.write(path, tweets); err != nil {
+		fmt.Fprintf(os.Stderr, "Could not cache %v: %v\n", src.Title, err)
+		err = nil
+	}
+	return
+}
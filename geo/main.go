@@ -0,0 +1,150 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Looks up Twitter places by name or coordinates.
+package main
+
+// Finds places by name, or near a point, with geo/search.json:
+//   $ go run ./geo -query="San Francisco"
+//   1.) San Francisco, CA (city, US)
+//       ID: 5a110d312052166f  Center: 37.7706565,-122.4359785
+//
+// Or lists the places containing a point with geo/reverse_geocode.json:
+//   $ go run ./geo -lat=37.7821 -long=-122.4093 -granularity=neighborhood reverse
+//
+// The IDs work as the place_id of a Tweet, see tweet_place.
+
+import (
+	"flag"
+	"fmt"
+	"github.com/kurrik/oauth1a"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
+	"github.com/kurrik/twittergo-examples/places"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"strings"
+)
+
+func LoadCredentials() (client *twittergo.Client, err error) {
+	credentials, err := ioutil.ReadFile("CREDENTIALS")
+	if err != nil {
+		return
+	}
+	lines := strings.Split(string(credentials), "\n")
+	config := &oauth1a.ClientConfig{
+		ConsumerKey:    lines[0],
+		ConsumerSecret: lines[1],
+	}
+	user := oauth1a.NewAuthorizedConfig(lines[2], lines[3])
+	client = twittergo.NewClient(config, user)
+	return
+}
+
+type Args struct {
+	Command     string
+	Query       string
+	Lat         string
+	Long        string
+	Granularity string
+	MaxResults  int
+	Output      string
+	Template    string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Query, "query", "", "Name of the place to search for")
+	flag.StringVar(&a.Lat, "lat", "", "Latitude to search near or reverse geocode")
+	flag.StringVar(&a.Long, "long", "", "Longitude to search near or reverse geocode")
+	flag.StringVar(&a.Granularity, "granularity", "", "Smallest kind of place: poi, neighborhood, city, admin or country")
+	flag.IntVar(&a.MaxResults, "max_results", 0, "Maximum number of places, 0 for the API default")
+	flag.StringVar(&a.Output, "output", output.Text, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
+	flag.Parse()
+	a.Command = flag.Arg(0)
+	return a
+}
+
+func main() {
+	var (
+		err    error
+		client *twittergo.Client
+		args   *Args
+		out    *output.Writer
+		found  []places.Place
+	)
+	args = parseArgs()
+	if out, err = output.NewWriter(os.Stdout, args.Output, args.Template, places.Schema); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	if client, err = LoadCredentials(); err != nil {
+		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+		out.Close()
+		os.Exit(1)
+	}
+	params := url.Values{}
+	if args.Granularity != "" {
+		params.Set("granularity", args.Granularity)
+	}
+	if args.MaxResults > 0 {
+		params.Set("max_results", fmt.Sprintf("%v", args.MaxResults))
+	}
+	switch args.Command {
+	case "", "search":
+		if args.Query == "" && (args.Lat == "" || args.Long == "") {
+			fmt.Printf("Specify a -query, or -lat and -long.\n")
+			out.Close()
+			os.Exit(1)
+		}
+		if args.Query != "" {
+			params.Set("query", args.Query)
+		}
+		if args.Lat != "" && args.Long != "" {
+			params.Set("lat", args.Lat)
+			params.Set("long", args.Long)
+		}
+		found, err = places.Search(client, params)
+	case "reverse":
+		lat, long, perr := places.ParseLatLong(args.Lat + "," + args.Long)
+		if perr != nil {
+			fmt.Printf("Specify a valid -lat and -long: %v\n", perr)
+			out.Close()
+			os.Exit(1)
+		}
+		found, err = places.Reverse(client, lat, long, params)
+	default:
+		fmt.Printf("Unknown command %v, use search or reverse.\n", args.Command)
+		out.Close()
+		os.Exit(1)
+	}
+	if err != nil {
+		fmt.Printf("Could not look up places: %v\n", err)
+		out.Close()
+		os.Exit(1)
+	}
+	for _, place := range found {
+		if err = out.Write(place); err != nil {
+			fmt.Printf("Could not write place: %v\n", err)
+			out.Close()
+			os.Exit(1)
+		}
+	}
+	out.Close()
+	if out.Count() == 0 {
+		out.Notef("No places found.\n")
+	}
+}
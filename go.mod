@@ -1,10 +1,11 @@
 module github.com/kurrik/twittergo-examples
 
-go 1.16
+go 1.17
 
 require (
 	github.com/codingneo/twittergo v0.0.0-20141112134736-a0faef95004b
 	github.com/kurrik/json v0.0.0-20160508230744-6b510c293ed2
 	github.com/kurrik/oauth1a v0.1.1
 	github.com/kurrik/twittergo v0.0.0-20201111073046-3e2792781fcf
+	golang.org/x/text v0.13.0
 )
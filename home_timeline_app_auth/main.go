@@ -17,9 +17,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/kurrik/oauth1a"
 	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -41,17 +43,38 @@ func LoadCredentials() (client *twittergo.Client, err error) {
 	return
 }
 
+type Args struct {
+	Output   string
+	Template string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Output, "output", output.Text, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
+	flag.Parse()
+	return a
+}
+
 func main() {
 	var (
 		err     error
 		client  *twittergo.Client
 		req     *http.Request
 		resp    *twittergo.APIResponse
-		results *twittergo.SearchResults
+		results *twittergo.Timeline
+		args    *Args
+		out     *output.Writer
 	)
+	args = parseArgs()
+	if out, err = output.NewWriter(os.Stdout, args.Output, args.Template, output.TweetSchema); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
 	client, err = LoadCredentials()
 	if err != nil {
 		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
 	query := url.Values{}
@@ -59,17 +82,28 @@ func main() {
 	req, err = http.NewRequest("GET", url, nil)
 	if err != nil {
 		fmt.Printf("Could not parse request: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
 	resp, err = client.SendRequest(req)
 	if err != nil {
 		fmt.Printf("Could not send request: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
-	results = &twittergo.SearchResults{}
+	results = &twittergo.Timeline{}
 	err = resp.Parse(results)
 	if err != nil {
 		fmt.Printf("There was an error: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
+	for _, tweet := range *results {
+		if err = out.Write(tweet); err != nil {
+			fmt.Printf("Could not write Tweet: %v\n", err)
+			out.Close()
+			os.Exit(1)
+		}
+	}
+	out.Close()
 }
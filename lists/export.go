@@ -0,0 +1,317 @@
+// Copyright 2013 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+// The export-members and export-timeline commands write the members or the
+// Tweets of a list to -out, as NDJSON (one JSON object per line) or CSV
+// depending on -format.  Both formats use the records and columns of the
+// output package, see output.UserSchema and output.FlatTweet:
+//   $ go run ./lists -list_id=123 -out=members.csv -format=csv export-members
+//   $ go run ./lists -list_id=123 -out=timeline.json export-timeline
+//
+// Pass -resume to continue an export which was interrupted.  Timelines are
+// paged backwards from the oldest Tweet already in the file.  Member exports
+// keep the next cursor and the size of the file after each page in a file
+// named after -out with a ".cursor" suffix, which is removed once the export
+// completes.  Resuming cuts the file back to that size first, so a page
+// interrupted before its checkpoint is fetched again rather than repeated.
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
+)
+
+// Reads the ID of the record on a line of an export file.  CSV headers
+// have no ID.
+func exportLineId(line string, format string) (id uint64, err error) {
+	if format == "csv" {
+		field := strings.SplitN(line, ",", 2)[0]
+		if field == "id" {
+			return
+		}
+		return strconv.ParseUint(field, 10, 64)
+	}
+	record := struct {
+		IdStr string `json:"id_str"`
+	}{}
+	if err = json.Unmarshal([]byte(line), &record); err != nil {
+		return
+	}
+	return strconv.ParseUint(record.IdStr, 10, 64)
+}
+
+// Opens path for an export.  With resume set, an existing file is kept,
+// any partially written last line is cut off, and the number of records and
+// lowest ID already in the file are returned.  Otherwise the file is
+// truncated.
+func openExport(path string, format string, resume bool, schema *output.Schema) (file *os.File, w *output.Writer, minId uint64, count int, err error) {
+	var (
+		reader *bufio.Reader
+		line   string
+		id     uint64
+		size   int64
+	)
+	if format != output.NDJSON && format != output.CSV {
+		err = fmt.Errorf("Format must be ndjson or csv, got %v", format)
+		return
+	}
+	flags := os.O_RDWR | os.O_CREATE
+	if !resume {
+		flags |= os.O_TRUNC
+	}
+	if file, err = os.OpenFile(path, flags, 0644); err != nil {
+		err = fmt.Errorf("Could not open output file %v: %v", path, err)
+		return
+	}
+	reader = bufio.NewReader(file)
+	record := ""
+	for {
+		if line, err = reader.ReadString('\n'); err == io.EOF {
+			err = nil
+			break
+		} else if err != nil {
+			file.Close()
+			return
+		}
+		record += line
+		if format == output.CSV && strings.Count(record, `"`)%2 != 0 {
+			// A quoted field, such as Tweet text, spans lines.
+			continue
+		}
+		if id, err = exportLineId(strings.TrimSpace(record), format); err != nil {
+			file.Close()
+			err = fmt.Errorf("Could not read %v at byte %v: %v", path, size, err)
+			return
+		}
+		size += int64(len(record))
+		record = ""
+		if id == 0 {
+			continue
+		}
+		if minId == 0 || id < minId {
+			minId = id
+		}
+		count++
+	}
+	if count == 0 {
+		// Nothing but a header, which is written again with the first row.
+		size = 0
+	}
+	if err = file.Truncate(size); err != nil {
+		file.Close()
+		return
+	}
+	if _, err = file.Seek(size, io.SeekStart); err != nil {
+		file.Close()
+		return
+	}
+	if w, err = output.NewWriter(file, format, "", schema); err != nil {
+		file.Close()
+		return
+	}
+	w.Continue(count)
+	return
+}
+
+// Reads a members checkpoint: the cursor of the next page and the size of
+// the export once every earlier page was written.  The size is -1 in
+// checkpoints which do not have one.
+func readCheckpoint(checkpoint string) (cursor string, size int64, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(checkpoint); err != nil {
+		return
+	}
+	size = -1
+	fields := strings.Fields(string(data))
+	if len(fields) > 0 {
+		cursor = fields[0]
+	}
+	if len(fields) > 1 {
+		if size, err = strconv.ParseInt(fields[1], 10, 64); err != nil {
+			err = fmt.Errorf("Could not read %v: %v", checkpoint, err)
+		}
+	}
+	return
+}
+
+// Saves the cursor of the next page along with the size of the export so
+// far, replacing the checkpoint at once so an interruption never leaves
+// half of one.  Resuming cuts the export back to size, dropping any rows of
+// the page which were written after the checkpoint.
+func writeCheckpoint(checkpoint string, cursor string, size int64) (err error) {
+	tmp := checkpoint + ".tmp"
+	if err = ioutil.WriteFile(tmp, []byte(fmt.Sprintf("%v %v", cursor, size)), 0644); err != nil {
+		return
+	}
+	return os.Rename(tmp, checkpoint)
+}
+
+func exportPath(args *Args, name string) string {
+	if args.OutputFile != "" {
+		return args.OutputFile
+	}
+	if args.Format == "csv" {
+		return name + ".csv"
+	}
+	return name + ".json"
+}
+
+func exportMembers(client *twittergo.Client, args *Args) (err error) {
+	var (
+		file   *os.File
+		w      *output.Writer
+		params url.Values
+		cursor string
+		size   int64
+		count  int
+	)
+	if params, err = listParams(args); err != nil {
+		return
+	}
+	path := exportPath(args, "list_members")
+	checkpoint := path + ".cursor"
+	if args.Resume {
+		if cursor, size, err = readCheckpoint(checkpoint); os.IsNotExist(err) {
+			if _, err = os.Stat(path); err == nil {
+				fmt.Printf("%v is already complete.\n", path)
+				return
+			}
+		} else if err != nil {
+			return
+		} else {
+			params.Set("cursor", cursor)
+			if size >= 0 {
+				if err = os.Truncate(path, size); err != nil {
+					return
+				}
+			}
+		}
+	}
+	if file, w, _, count, err = openExport(path, args.Format, args.Resume, output.UserSchema); err != nil {
+		return
+	}
+	defer file.Close()
+	if cursor == "" {
+		// Mark the export as started so that -resume can tell it is unfinished.
+		if err = writeCheckpoint(checkpoint, "-1", 0); err != nil {
+			return
+		}
+	} else {
+		fmt.Printf("Resuming after %v members, from cursor %v.\n", count, cursor)
+	}
+	err = fetchMembers(client, params, func(users []twittergo.User, next string) (err error) {
+		for _, user := range users {
+			if err = w.Write(user); err != nil {
+				return fmt.Errorf("Could not write member: %v", err)
+			}
+		}
+		if size, err = file.Seek(0, io.SeekCurrent); err != nil {
+			return
+		}
+		fmt.Printf("Got %v members, %v total.\n", len(users), w.Count())
+		return writeCheckpoint(checkpoint, next, size)
+	})
+	if err != nil {
+		return
+	}
+	if err = w.Close(); err != nil {
+		return
+	}
+	os.Remove(checkpoint)
+	fmt.Printf("--------------------------------------------------------\n")
+	fmt.Printf("Wrote %v members to %v\n", w.Count(), path)
+	return
+}
+
+func exportTimeline(client *twittergo.Client, args *Args) (err error) {
+	var (
+		file    *os.File
+		w       *output.Writer
+		req     *http.Request
+		resp    *twittergo.APIResponse
+		query   url.Values
+		results *twittergo.Timeline
+		max_id  uint64
+		total   int
+	)
+	if query, err = listParams(args); err != nil {
+		return
+	}
+	path := exportPath(args, "list_timeline")
+	if file, w, max_id, total, err = openExport(path, args.Format, args.Resume, output.TweetSchema); err != nil {
+		return
+	}
+	defer file.Close()
+	if max_id != 0 {
+		fmt.Printf("Resuming after %v Tweets, from ID %v.\n", total, max_id)
+		max_id -= 1
+	}
+	query.Set("count", "200")
+	query.Set("include_rts", "true")
+	query.Set("tweet_mode", "extended")
+	for {
+		if max_id != 0 {
+			query.Set("max_id", fmt.Sprintf("%v", max_id))
+		}
+		endpoint := fmt.Sprintf("/1.1/lists/statuses.json?%v", query.Encode())
+		if req, err = http.NewRequest("GET", endpoint, nil); err != nil {
+			return fmt.Errorf("Could not parse request: %v\n", err)
+		}
+		if resp, err = client.SendRequest(req); err != nil {
+			return fmt.Errorf("Could not send request: %v\n", err)
+		}
+		results = &twittergo.Timeline{}
+		if err = resp.Parse(results); err != nil {
+			if err = handleRateLimit(err); err != nil {
+				return fmt.Errorf("Problem parsing response: %v\n", describeError(err))
+			}
+			continue
+		}
+		batch := len(*results)
+		if batch == 0 {
+			fmt.Printf("No more results, end of timeline.\n")
+			break
+		}
+		for _, tweet := range *results {
+			if err = w.Write(tweet); err != nil {
+				return fmt.Errorf("Could not write Tweet: %v", err)
+			}
+			max_id = tweet.Id() - 1
+			total += 1
+		}
+		fmt.Printf("Got %v Tweets", batch)
+		if resp.HasRateLimit() {
+			fmt.Printf(", %v calls available", resp.RateLimitRemaining())
+		}
+		fmt.Printf(".\n")
+	}
+	if err = w.Close(); err != nil {
+		return
+	}
+	fmt.Printf("--------------------------------------------------------\n")
+	fmt.Printf("Wrote %v Tweets to %v\n", total, path)
+	return
+}
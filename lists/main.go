@@ -12,11 +12,38 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Prints and manages Twitter lists.
 package main
 
+// Run without a command (or with "show") to print the lists a user owns,
+// subscribes to or is a member of.  Use -output to print them as json,
+// ndjson, csv, tsv or with a -template:
+//   $ go run ./lists -screen_name=kurrik
+//   $ go run ./lists -screen_name=kurrik -output=csv > lists.csv
+//
+// The remaining commands modify lists owned by the authenticated user.  Pass
+// -dry_run to print what would change without calling the API:
+//   create       Create a list from -name, -mode and -description.
+//   update       Change the -name, -mode or -description of a list.  Only
+//                the flags given are changed; -description="" clears it.
+//   destroy      Delete a list.
+//   add          Add the comma separated -members to a list.
+//   remove       Remove the comma separated -members from a list.
+//   subscribe    Subscribe the authenticated user to a list.
+//   unsubscribe  Unsubscribe the authenticated user from a list.
+//   sync         Make the members of a list match the screen names in -file.
+//
+// The export-members and export-timeline commands write the members or the
+// Tweets of any list to -out, see export.go.
+//
+// Lists are identified with -list_id, or with -slug and -owner_screen_name:
+//   $ go run ./lists -slug=team -owner_screen_name=kurrik \
+//       -members=episod,twitterapi -dry_run add
+
 import (
 	"flag"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -26,6 +53,7 @@ import (
 
 	"github.com/kurrik/oauth1a"
 	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
 )
 
 const MINWAIT = time.Duration(10) * time.Second
@@ -46,15 +74,51 @@ func LoadCredentials() (client *twittergo.Client, err error) {
 }
 
 type Args struct {
-	ScreenName string
-	Count      string
+	Command         string
+	ScreenName      string
+	Count           string
+	ListId          string
+	Slug            string
+	OwnerScreenName string
+	Name            string
+	Mode            string
+	Description     string
+	Members         string
+	File            string
+	OutputFile      string
+	Format          string
+	Resume          bool
+	DryRun          bool
+	Output          string
+	Template        string
+	// Flags given on the command line, even if set to an empty value.
+	Set map[string]bool
 }
 
 func parseArgs() *Args {
 	a := &Args{}
 	flag.StringVar(&a.ScreenName, "screen_name", "episod", "Screen name to look up")
 	flag.StringVar(&a.Count, "count", "100", "Number of results / page")
+	flag.StringVar(&a.ListId, "list_id", "", "ID of the list to modify")
+	flag.StringVar(&a.Slug, "slug", "", "Slug of the list to modify")
+	flag.StringVar(&a.OwnerScreenName, "owner_screen_name", "", "Owner of the list named by -slug")
+	flag.StringVar(&a.Name, "name", "", "Name of the list to create or update")
+	flag.StringVar(&a.Mode, "mode", "", "List mode, public or private")
+	flag.StringVar(&a.Description, "description", "", "Description of the list")
+	flag.StringVar(&a.Members, "members", "", "Comma separated screen names to add or remove")
+	flag.StringVar(&a.File, "file", "", "CSV or YAML file of screen names to sync")
+	flag.StringVar(&a.OutputFile, "out", "", "Output file for exports")
+	flag.StringVar(&a.Format, "format", "ndjson", "Export format, ndjson or csv")
+	flag.BoolVar(&a.Resume, "resume", false, "Continue an interrupted export")
+	flag.BoolVar(&a.DryRun, "dry_run", false, "Print what would change without changing it")
+	flag.StringVar(&a.Output, "output", output.Text, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
 	flag.Parse()
+	a.Command = flag.Arg(0)
+	a.Set = map[string]bool{}
+	flag.Visit(func(f *flag.Flag) {
+		a.Set[f.Name] = true
+	})
 	return a
 }
 
@@ -66,7 +130,7 @@ func handleRateLimit(err error) error {
 			dur = MINWAIT
 		}
 		msg := "Rate limited. Reset at %v. Waiting for %v\n"
-		fmt.Printf(msg, rle.Reset, dur)
+		fmt.Fprintf(os.Stderr, msg, rle.Reset, dur)
 		time.Sleep(dur)
 		return nil
 	}
@@ -83,15 +147,64 @@ func printRateLimit(resp *twittergo.APIResponse) {
 	}
 }
 
-func printList(list *twittergo.List) {
+// Like printRateLimit, but keeps machine readable output clean.
+func noteRateLimit(out *output.Writer, resp *twittergo.APIResponse) {
+	if resp.HasRateLimit() {
+		out.Notef("Rate limit:           %v\n", resp.RateLimit())
+		out.Notef("Rate limit remaining: %v\n", resp.RateLimitRemaining())
+		out.Notef("Rate limit reset:     %v\n", resp.RateLimitReset())
+	} else {
+		out.Notef("Could not parse rate limit from response.\n")
+	}
+}
+
+func writeList(w io.Writer, list *twittergo.List) (err error) {
 	user := list.User()
-	fmt.Printf("%v\n", list.Name())
-	fmt.Printf("Owner: %v (@%v)\n", user.Name(), user.ScreenName())
-	fmt.Printf("Members: %v\n", list.MemberCount())
-	fmt.Printf("Subscribers: %v\n\n", list.SubscriberCount())
+	_, err = fmt.Fprintf(w, "%v\nOwner: %v (@%v)\nMembers: %v\nSubscribers: %v\n\n",
+		list.Name(), user.Name(), user.ScreenName(), listCount(list, "member_count"), listCount(list, "subscriber_count"))
+	return
+}
+
+func printList(list *twittergo.List) {
+	writeList(os.Stdout, list)
+}
+
+// A list printed by the show command, along with how the user relates to it:
+// "list", "member", "subscriber" or "owner".
+type ListRecord struct {
+	Relation string         `json:"relation"`
+	Index    int            `json:"-"`
+	List     twittergo.List `json:"list"`
 }
 
-func fetchAndPrintList(client *twittergo.Client, path string, query url.Values) (err error) {
+func listColumn(name string, value func(*ListRecord) string) output.Column {
+	return output.Column{Name: name, Value: func(record interface{}) string {
+		return value(record.(*ListRecord))
+	}}
+}
+
+var ListSchema = &output.Schema{
+	Columns: []output.Column{
+		listColumn("relation", func(r *ListRecord) string { return r.Relation }),
+		listColumn("id", func(r *ListRecord) string { return r.List.IdStr() }),
+		listColumn("full_name", func(r *ListRecord) string { return listField(&r.List, "full_name") }),
+		listColumn("name", func(r *ListRecord) string { return r.List.Name() }),
+		listColumn("mode", func(r *ListRecord) string { return r.List.Mode() }),
+		listColumn("owner", func(r *ListRecord) string { return r.List.User().ScreenName() }),
+		listColumn("member_count", func(r *ListRecord) string { return listCount(&r.List, "member_count") }),
+		listColumn("subscriber_count", func(r *ListRecord) string { return listCount(&r.List, "subscriber_count") }),
+		listColumn("description", func(r *ListRecord) string { return listField(&r.List, "description") }),
+	},
+	Text: func(w io.Writer, i int, record interface{}) (err error) {
+		r := record.(*ListRecord)
+		if _, err = fmt.Fprintf(w, "%v.) ", r.Index); err != nil {
+			return
+		}
+		return writeList(w, &r.List)
+	},
+}
+
+func fetchAndPrintList(client *twittergo.Client, out *output.Writer, relation string, path string, query url.Values) (err error) {
 	var (
 		req     *http.Request
 		resp    *twittergo.APIResponse
@@ -100,11 +213,11 @@ func fetchAndPrintList(client *twittergo.Client, path string, query url.Values)
 	for {
 		url := fmt.Sprintf("%v?%v", path, query.Encode())
 		req, err = http.NewRequest("GET", url, nil)
-		req.Header.Set("Accept-Encoding", "gzip, deflate")
 		if err != nil {
 			err = fmt.Errorf("Could not parse request: %v\n", err)
 			return
 		}
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
 		resp, err = client.SendRequest(req)
 		if err != nil {
 			err = fmt.Errorf("Could not send request: %v\n", err)
@@ -119,34 +232,34 @@ func fetchAndPrintList(client *twittergo.Client, path string, query url.Values)
 				continue
 			}
 		}
-		fmt.Printf("\n")
+		out.Notef("\n")
 		for i, list := range results {
-			fmt.Printf("%v.) ", i+1)
-			printList(&list)
+			if err = out.Write(&ListRecord{relation, i + 1, list}); err != nil {
+				return
+			}
 		}
-		printRateLimit(resp)
+		noteRateLimit(out, resp)
 		return
 	}
-	return
 }
 
-func fetchAndPrintCursoredList(client *twittergo.Client, path string, query url.Values) (err error) {
+func fetchAndPrintCursoredList(client *twittergo.Client, out *output.Writer, relation string, path string, query url.Values) (err error) {
 	var (
 		req     *http.Request
 		resp    *twittergo.APIResponse
 		results twittergo.CursoredLists
-		i       int64
+		i       int
 	)
 	i = 1
 	query.Set("cursor", "-1")
 	for {
 		url := fmt.Sprintf("%v?%v", path, query.Encode())
 		req, err = http.NewRequest("GET", url, nil)
-		req.Header.Set("Accept-Encoding", "gzip, deflate")
 		if err != nil {
 			err = fmt.Errorf("Could not parse request: %v\n", err)
 			break
 		}
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
 		resp, err = client.SendRequest(req)
 		if err != nil {
 			err = fmt.Errorf("Could not send request: %v\n", err)
@@ -161,13 +274,14 @@ func fetchAndPrintCursoredList(client *twittergo.Client, path string, query url.
 				continue
 			}
 		}
-		fmt.Printf("\n")
+		out.Notef("\n")
 		for _, list := range results.Lists() {
-			fmt.Printf("%v.) ", i)
-			printList(&list)
+			if err = out.Write(&ListRecord{relation, i, list}); err != nil {
+				return
+			}
 			i += 1
 		}
-		printRateLimit(resp)
+		noteRateLimit(out, resp)
 		if results.NextCursorStr() == "0" {
 			break
 		}
@@ -176,47 +290,86 @@ func fetchAndPrintCursoredList(client *twittergo.Client, path string, query url.
 	return
 }
 
-func main() {
-	var (
-		err    error
-		args   *Args
-		client *twittergo.Client
-	)
-	args = parseArgs()
-	if client, err = LoadCredentials(); err != nil {
-		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
-		os.Exit(1)
+func printLists(client *twittergo.Client, args *Args) (err error) {
+	var out *output.Writer
+	if out, err = output.NewWriter(os.Stdout, args.Output, args.Template, ListSchema); err != nil {
+		return
 	}
+	defer out.Close()
 	query := url.Values{}
 	query.Set("screen_name", args.ScreenName)
 
-	fmt.Printf("Printing up to 100 lists %v owns or is subscribed to:\n", args.ScreenName)
-	fmt.Printf("=========================================================\n")
-	if err = fetchAndPrintList(client, "/1.1/lists/list.json", query); err != nil {
-		fmt.Println("Error: %v\n", err)
+	out.Notef("Printing up to 100 lists %v owns or is subscribed to:\n", args.ScreenName)
+	out.Notef("=========================================================\n")
+	if err = fetchAndPrintList(client, out, "list", "/1.1/lists/list.json", query); err != nil {
+		out.Notef("Error: %v\n", err)
 	}
-	fmt.Printf("\n\n")
+	out.Notef("\n\n")
 
 	// Add count for future requests
 	query.Set("count", args.Count)
 
-	fmt.Printf("Printing the lists %v is a member of:\n", args.ScreenName)
-	fmt.Printf("=========================================================\n")
-	if err = fetchAndPrintCursoredList(client, "/1.1/lists/memberships.json", query); err != nil {
-		fmt.Printf("Error: %v\n", err)
+	out.Notef("Printing the lists %v is a member of:\n", args.ScreenName)
+	out.Notef("=========================================================\n")
+	if err = fetchAndPrintCursoredList(client, out, "member", "/1.1/lists/memberships.json", query); err != nil {
+		out.Notef("Error: %v\n", err)
 	}
-	fmt.Printf("\n\n")
+	out.Notef("\n\n")
 
-	fmt.Printf("Printing the lists %v is subscribed to:\n", args.ScreenName)
-	fmt.Printf("=========================================================\n")
-	if err = fetchAndPrintCursoredList(client, "/1.1/lists/subscriptions.json", query); err != nil {
-		fmt.Printf("Error: %v\n", err)
+	out.Notef("Printing the lists %v is subscribed to:\n", args.ScreenName)
+	out.Notef("=========================================================\n")
+	if err = fetchAndPrintCursoredList(client, out, "subscriber", "/1.1/lists/subscriptions.json", query); err != nil {
+		out.Notef("Error: %v\n", err)
+	}
+	out.Notef("\n\n")
+
+	out.Notef("Printing the lists %v is owner of:\n", args.ScreenName)
+	out.Notef("=========================================================\n")
+	if err = fetchAndPrintCursoredList(client, out, "owner", "/1.1/lists/ownerships.json", query); err != nil {
+		out.Notef("Error: %v\n", err)
 	}
-	fmt.Printf("\n\n")
+	return nil
+}
 
-	fmt.Printf("Printing the lists %v is owner of:\n", args.ScreenName)
-	fmt.Printf("=========================================================\n")
-	if err = fetchAndPrintCursoredList(client, "/1.1/lists/ownerships.json", query); err != nil {
+func main() {
+	var (
+		err    error
+		args   *Args
+		client *twittergo.Client
+	)
+	args = parseArgs()
+	if client, err = LoadCredentials(); err != nil {
+		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+		os.Exit(1)
+	}
+	switch args.Command {
+	case "", "show":
+		err = printLists(client, args)
+	case "create":
+		err = createList(client, args)
+	case "update":
+		err = updateList(client, args)
+	case "destroy":
+		err = destroyList(client, args)
+	case "add":
+		err = modifyMembers(client, args, "/1.1/lists/members/create_all.json", "add")
+	case "remove":
+		err = modifyMembers(client, args, "/1.1/lists/members/destroy_all.json", "remove")
+	case "sync":
+		err = syncList(client, args)
+	case "export-members":
+		err = exportMembers(client, args)
+	case "export-timeline":
+		err = exportTimeline(client, args)
+	case "subscribe":
+		err = modifySubscription(client, args, "/1.1/lists/subscribers/create.json", "subscribe to")
+	case "unsubscribe":
+		err = modifySubscription(client, args, "/1.1/lists/subscribers/destroy.json", "unsubscribe from")
+	default:
+		err = fmt.Errorf("Unknown command: %v", args.Command)
+	}
+	if err != nil {
 		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
 	}
 }
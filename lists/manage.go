@@ -0,0 +1,348 @@
+// Copyright 2013 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kurrik/twittergo"
+)
+
+// The members/create_all and members/destroy_all endpoints accept at most
+// this many users per call.
+const MAXBATCH = 100
+
+// Wraps API errors so that each error code returned by Twitter is listed.
+func describeError(err error) error {
+	if errs, ok := err.(twittergo.Errors); ok {
+		msgs := []string{}
+		for _, val := range errs.Errors() {
+			msgs = append(msgs, fmt.Sprintf("Code: %v Msg: %v", val.Code(), val.Message()))
+		}
+		return fmt.Errorf("%v", strings.Join(msgs, "; "))
+	}
+	return err
+}
+
+// Returns the parameters identifying the list named on the command line.
+func listParams(args *Args) (params url.Values, err error) {
+	params = url.Values{}
+	switch {
+	case args.ListId != "":
+		params.Set("list_id", args.ListId)
+	case args.Slug != "" && args.OwnerScreenName != "":
+		params.Set("slug", args.Slug)
+		params.Set("owner_screen_name", args.OwnerScreenName)
+	default:
+		err = fmt.Errorf("Specify -list_id, or -slug and -owner_screen_name")
+	}
+	return
+}
+
+// Splits names into groups no larger than size.
+func batches(names []string, size int) (out [][]string) {
+	for len(names) > size {
+		out = append(out, names[:size])
+		names = names[size:]
+	}
+	if len(names) > 0 {
+		out = append(out, names)
+	}
+	return
+}
+
+// Splits a comma separated list of screen names, dropping blanks and @s.
+func parseMembers(members string) (names []string) {
+	for _, name := range strings.Split(members, ",") {
+		name = strings.TrimPrefix(strings.TrimSpace(name), "@")
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return
+}
+
+func sendListRequest(client *twittergo.Client, method string, path string, params url.Values) (list *twittergo.List, resp *twittergo.APIResponse, err error) {
+	var req *http.Request
+	for {
+		if method == "GET" {
+			req, err = http.NewRequest(method, fmt.Sprintf("%v?%v", path, params.Encode()), nil)
+		} else {
+			req, err = http.NewRequest(method, path, strings.NewReader(params.Encode()))
+		}
+		if err != nil {
+			err = fmt.Errorf("Could not parse request: %v\n", err)
+			return
+		}
+		if method != "GET" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if resp, err = client.SendRequest(req); err != nil {
+			err = fmt.Errorf("Could not send request: %v\n", err)
+			return
+		}
+		list = &twittergo.List{}
+		if err = resp.Parse(list); err != nil {
+			if err = handleRateLimit(err); err != nil {
+				err = fmt.Errorf("Problem parsing response: %v\n", describeError(err))
+				return
+			}
+			continue
+		}
+		return
+	}
+}
+
+// Returns a string field of list which twittergo.List has no accessor for.
+func listField(list *twittergo.List, key string) string {
+	if value, ok := (*list)[key].(string); ok {
+		return value
+	}
+	return ""
+}
+
+// Returns a count of list as an integer.  The member_count and
+// subscriber_count fields are decoded as float64, which the
+// twittergo.List accessors do not expect.
+func listCount(list *twittergo.List, key string) string {
+	if value, ok := (*list)[key].(float64); ok {
+		return strconv.FormatInt(int64(value), 10)
+	}
+	return ""
+}
+
+func fetchList(client *twittergo.Client, args *Args) (list *twittergo.List, err error) {
+	var params url.Values
+	if params, err = listParams(args); err != nil {
+		return
+	}
+	list, _, err = sendListRequest(client, "GET", "/1.1/lists/show.json", params)
+	return
+}
+
+func checkMode(mode string) error {
+	if mode != "" && mode != "public" && mode != "private" {
+		return fmt.Errorf("Mode must be public or private, got %v", mode)
+	}
+	return nil
+}
+
+func createList(client *twittergo.Client, args *Args) (err error) {
+	var (
+		list *twittergo.List
+		resp *twittergo.APIResponse
+	)
+	if args.Name == "" {
+		return fmt.Errorf("Specify a -name for the new list")
+	}
+	if err = checkMode(args.Mode); err != nil {
+		return
+	}
+	params := url.Values{}
+	params.Set("name", args.Name)
+	if args.Mode != "" {
+		params.Set("mode", args.Mode)
+	}
+	if args.Description != "" {
+		params.Set("description", args.Description)
+	}
+	fmt.Printf("Create list %v\n", args.Name)
+	fmt.Printf("  mode:        %v\n", params.Get("mode"))
+	fmt.Printf("  description: %v\n", params.Get("description"))
+	if args.DryRun {
+		fmt.Printf("Dry run, not creating list.\n")
+		return
+	}
+	if list, resp, err = sendListRequest(client, "POST", "/1.1/lists/create.json", params); err != nil {
+		return
+	}
+	fmt.Printf("\nCreated list %v:\n", list.IdStr())
+	printList(list)
+	printRateLimit(resp)
+	return
+}
+
+func updateList(client *twittergo.Client, args *Args) (err error) {
+	var (
+		list    *twittergo.List
+		resp    *twittergo.APIResponse
+		params  url.Values
+		changes int
+	)
+	if err = checkMode(args.Mode); err != nil {
+		return
+	}
+	if params, err = listParams(args); err != nil {
+		return
+	}
+	if list, err = fetchList(client, args); err != nil {
+		return
+	}
+	fmt.Printf("Update list %v (%v)\n", listField(list, "full_name"), list.IdStr())
+	for _, field := range []struct {
+		name    string
+		current string
+		desired string
+	}{
+		{"name", list.Name(), args.Name},
+		{"mode", list.Mode(), args.Mode},
+		{"description", listField(list, "description"), args.Description},
+	} {
+		// Fields are only changed when their flag is given, so that
+		// -description="" clears the description.
+		if !args.Set[field.name] || field.desired == field.current {
+			continue
+		}
+		fmt.Printf("  %v: %q -> %q\n", field.name, field.current, field.desired)
+		params.Set(field.name, field.desired)
+		changes++
+	}
+	if changes == 0 {
+		fmt.Printf("Nothing to update.\n")
+		return
+	}
+	if args.DryRun {
+		fmt.Printf("Dry run, not updating list.\n")
+		return
+	}
+	if list, resp, err = sendListRequest(client, "POST", "/1.1/lists/update.json", params); err != nil {
+		return
+	}
+	fmt.Printf("\nUpdated list:\n")
+	printList(list)
+	printRateLimit(resp)
+	return
+}
+
+func destroyList(client *twittergo.Client, args *Args) (err error) {
+	var (
+		list   *twittergo.List
+		resp   *twittergo.APIResponse
+		params url.Values
+	)
+	if params, err = listParams(args); err != nil {
+		return
+	}
+	if list, err = fetchList(client, args); err != nil {
+		return
+	}
+	fmt.Printf("Destroy list %v (%v):\n", listField(list, "full_name"), list.IdStr())
+	printList(list)
+	if args.DryRun {
+		fmt.Printf("Dry run, not destroying list.\n")
+		return
+	}
+	if _, resp, err = sendListRequest(client, "POST", "/1.1/lists/destroy.json", params); err != nil {
+		return
+	}
+	fmt.Printf("Destroyed list.\n")
+	printRateLimit(resp)
+	return
+}
+
+// Adds or removes args.Members in batches of MAXBATCH using path.
+func modifyMembers(client *twittergo.Client, args *Args, path string, verb string) (err error) {
+	var (
+		resp   *twittergo.APIResponse
+		params url.Values
+		names  []string
+	)
+	if params, err = listParams(args); err != nil {
+		return
+	}
+	if names = parseMembers(args.Members); len(names) == 0 {
+		return fmt.Errorf("Specify the -members to %v", verb)
+	}
+	groups := batches(names, MAXBATCH)
+	fmt.Printf("Members to %v: %v in %v requests\n", verb, len(names), len(groups))
+	for i, group := range groups {
+		fmt.Printf("  Batch %v: %v\n", i+1, strings.Join(group, ", "))
+	}
+	if args.DryRun {
+		fmt.Printf("Dry run, not modifying members.\n")
+		return
+	}
+	if resp, err = applyMembers(client, params, path, groups); err != nil {
+		return
+	}
+	printRateLimit(resp)
+	return
+}
+
+// Sends one request to path for each group of screen names, pausing when
+// the rate limit for the endpoint has been used up.
+func applyMembers(client *twittergo.Client, params url.Values, path string, groups [][]string) (resp *twittergo.APIResponse, err error) {
+	var list *twittergo.List
+	for i, group := range groups {
+		// Copied so the caller's params never carry a screen_name.
+		batch := url.Values{}
+		for key, val := range params {
+			batch[key] = val
+		}
+		batch.Set("screen_name", strings.Join(group, ","))
+		if list, resp, err = sendListRequest(client, "POST", path, batch); err != nil {
+			err = fmt.Errorf("Batch %v failed: %v", i+1, err)
+			return
+		}
+		fmt.Printf("Batch %v done, %v now has %v members.\n", i+1, listField(list, "full_name"), listCount(list, "member_count"))
+		if i < len(groups)-1 {
+			waitForRateLimit(resp)
+		}
+	}
+	return
+}
+
+// Sleeps until the rate limit resets if resp says no calls remain.
+func waitForRateLimit(resp *twittergo.APIResponse) {
+	if !resp.HasRateLimit() || resp.RateLimitRemaining() > 0 {
+		return
+	}
+	dur := resp.RateLimitReset().Sub(time.Now()) + time.Second
+	if dur < MINWAIT {
+		dur = MINWAIT
+	}
+	fmt.Printf("No calls remaining. Reset at %v. Waiting for %v\n", resp.RateLimitReset(), dur)
+	time.Sleep(dur)
+}
+
+func modifySubscription(client *twittergo.Client, args *Args, path string, verb string) (err error) {
+	var (
+		list   *twittergo.List
+		resp   *twittergo.APIResponse
+		params url.Values
+	)
+	if params, err = listParams(args); err != nil {
+		return
+	}
+	if list, err = fetchList(client, args); err != nil {
+		return
+	}
+	fmt.Printf("Going to %v %v (%v)\n", verb, listField(list, "full_name"), list.IdStr())
+	if args.DryRun {
+		fmt.Printf("Dry run, not changing subscription.\n")
+		return
+	}
+	if list, resp, err = sendListRequest(client, "POST", path, params); err != nil {
+		return
+	}
+	fmt.Printf("Done, %v now has %v subscribers.\n", listField(list, "full_name"), listCount(list, "subscriber_count"))
+	printRateLimit(resp)
+	return
+}
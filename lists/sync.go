@@ -0,0 +1,280 @@
+// Copyright 2013 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+// The sync command makes the membership of a list match a file of screen
+// names.  The file may be CSV, where the first column of each row is a
+// screen name (a "screen_name" header row is skipped):
+//   screen_name,note
+//   kurrik,author
+//   episod,
+//
+// Or a YAML sequence, either at the top level or under a single key:
+//   members:
+//     - kurrik
+//     - episod  # comments are ignored
+//
+// The plan is always printed.  Unless -dry_run is set, members are then
+// removed and added in batches and the list is fetched again to report
+// anything Twitter declined to change:
+//   $ go run ./lists -list_id=123 -file=team.yaml sync
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kurrik/twittergo"
+)
+
+// A page of users returned by cursored endpoints like lists/members.json.
+type CursoredUsers struct {
+	Users         []twittergo.User `json:"users"`
+	NextCursorStr string           `json:"next_cursor_str"`
+}
+
+// The changes needed to make a list match the desired members.
+type MembersPlan struct {
+	Add       []string
+	Remove    []string
+	Unchanged int
+}
+
+func (p *MembersPlan) Empty() bool {
+	return len(p.Add) == 0 && len(p.Remove) == 0
+}
+
+// Calls handler with each page of members of the list identified by params,
+// along with the cursor for the following page.  Paging starts from the
+// cursor in params, or from the first page if it is not set.
+func fetchMembers(client *twittergo.Client, params url.Values, handler func([]twittergo.User, string) error) (err error) {
+	var (
+		req     *http.Request
+		resp    *twittergo.APIResponse
+		results CursoredUsers
+	)
+	query := url.Values{}
+	for key, val := range params {
+		query[key] = val
+	}
+	query.Set("count", "5000")
+	query.Set("skip_status", "true")
+	query.Set("include_entities", "false")
+	if query.Get("cursor") == "" {
+		query.Set("cursor", "-1")
+	}
+	for {
+		url := fmt.Sprintf("%v?%v", "/1.1/lists/members.json", query.Encode())
+		req, err = http.NewRequest("GET", url, nil)
+		if err != nil {
+			err = fmt.Errorf("Could not parse request: %v\n", err)
+			break
+		}
+		req.Header.Set("Accept-Encoding", "gzip, deflate")
+		resp, err = client.SendRequest(req)
+		if err != nil {
+			err = fmt.Errorf("Could not send request: %v\n", err)
+			break
+		}
+		results = CursoredUsers{}
+		if err = resp.Parse(&results); err != nil {
+			if err = handleRateLimit(err); err != nil {
+				err = fmt.Errorf("Problem parsing response: %v\n", describeError(err))
+				break
+			} else {
+				continue
+			}
+		}
+		if err = handler(results.Users, results.NextCursorStr); err != nil {
+			break
+		}
+		if results.NextCursorStr == "0" || results.NextCursorStr == "" {
+			break
+		}
+		query.Set("cursor", results.NextCursorStr)
+	}
+	return
+}
+
+func listMembers(client *twittergo.Client, params url.Values) (users []twittergo.User, err error) {
+	err = fetchMembers(client, params, func(page []twittergo.User, next string) error {
+		users = append(users, page...)
+		return nil
+	})
+	return
+}
+
+// Reads the desired screen names from a CSV or YAML file.
+func readMembersFile(path string) (names []string, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(path); err != nil {
+		return
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		names, err = parseMembersCSV(data)
+	case ".yaml", ".yml":
+		names = parseMembersYAML(data)
+	default:
+		err = fmt.Errorf("Unsupported members file %v, use .csv, .yaml or .yml", path)
+	}
+	return
+}
+
+func parseMembersCSV(data []byte) (names []string, err error) {
+	var record []string
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	for {
+		if record, err = reader.Read(); err == io.EOF {
+			err = nil
+			break
+		} else if err != nil {
+			return
+		}
+		if len(names) == 0 && strings.EqualFold(record[0], "screen_name") {
+			continue
+		}
+		names = append(names, parseMembers(record[0])...)
+	}
+	return
+}
+
+// Only sequences of scalars are understood, which is all a list of screen
+// names needs.
+func parseMembersYAML(data []byte) (names []string) {
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "- ") {
+			continue
+		}
+		name := strings.Trim(strings.TrimSpace(line[2:]), `"'`)
+		names = append(names, parseMembers(name)...)
+	}
+	return
+}
+
+// Compares screen names case insensitively, as Twitter does.
+func planMembers(desired []string, current []twittergo.User) (plan *MembersPlan) {
+	plan = &MembersPlan{}
+	wanted := map[string]string{}
+	for _, name := range desired {
+		wanted[strings.ToLower(name)] = name
+	}
+	for _, user := range current {
+		key := strings.ToLower(user.ScreenName())
+		if _, ok := wanted[key]; ok {
+			plan.Unchanged++
+			delete(wanted, key)
+		} else {
+			plan.Remove = append(plan.Remove, user.ScreenName())
+		}
+	}
+	for _, name := range wanted {
+		plan.Add = append(plan.Add, name)
+	}
+	sort.Strings(plan.Add)
+	sort.Strings(plan.Remove)
+	return
+}
+
+func printPlan(list *twittergo.List, plan *MembersPlan) {
+	fmt.Printf("Plan for %v (%v):\n", listField(list, "full_name"), list.IdStr())
+	for _, name := range plan.Add {
+		fmt.Printf("  + %v\n", name)
+	}
+	for _, name := range plan.Remove {
+		fmt.Printf("  - %v\n", name)
+	}
+	fmt.Printf("Plan: %v to add, %v to remove, %v unchanged.\n",
+		len(plan.Add), len(plan.Remove), plan.Unchanged)
+}
+
+func syncList(client *twittergo.Client, args *Args) (err error) {
+	var (
+		list    *twittergo.List
+		resp    *twittergo.APIResponse
+		params  url.Values
+		desired []string
+		current []twittergo.User
+		plan    *MembersPlan
+	)
+	if params, err = listParams(args); err != nil {
+		return
+	}
+	if args.File == "" {
+		return fmt.Errorf("Specify the -file listing the desired members")
+	}
+	if desired, err = readMembersFile(args.File); err != nil {
+		return
+	}
+	if list, err = fetchList(client, args); err != nil {
+		return
+	}
+	if current, err = listMembers(client, params); err != nil {
+		return
+	}
+	plan = planMembers(desired, current)
+	printPlan(list, plan)
+	if plan.Empty() {
+		fmt.Printf("No changes, %v is up to date.\n", listField(list, "full_name"))
+		return
+	}
+	if args.DryRun {
+		fmt.Printf("Dry run, not applying plan.\n")
+		return
+	}
+	// Remove first so that the list never goes over its member limit.
+	if len(plan.Remove) > 0 {
+		fmt.Printf("\nRemoving %v members:\n", len(plan.Remove))
+		groups := batches(plan.Remove, MAXBATCH)
+		if resp, err = applyMembers(client, params, "/1.1/lists/members/destroy_all.json", groups); err != nil {
+			return
+		}
+	}
+	if len(plan.Add) > 0 {
+		if resp != nil {
+			waitForRateLimit(resp)
+		}
+		fmt.Printf("\nAdding %v members:\n", len(plan.Add))
+		groups := batches(plan.Add, MAXBATCH)
+		if resp, err = applyMembers(client, params, "/1.1/lists/members/create_all.json", groups); err != nil {
+			return
+		}
+	}
+	fmt.Printf("\nApply complete: %v added, %v removed.\n", len(plan.Add), len(plan.Remove))
+	printRateLimit(resp)
+	// Twitter silently skips accounts it will not add, such as suspended
+	// users, so check what actually changed.
+	if current, err = listMembers(client, params); err != nil {
+		return
+	}
+	if plan = planMembers(desired, current); !plan.Empty() {
+		fmt.Printf("\nList is still out of sync:\n")
+		printPlan(list, plan)
+	}
+	return
+}
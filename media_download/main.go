@@ -0,0 +1,277 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Downloads the photos, GIFs and videos of archived Tweets.
+package main
+
+// Reads the files written by user_timeline, favorites or tweet_hydrate and
+// downloads the media in their extended_entities.  No credentials are
+// needed:
+//   $ go run ./media_download -dir=media user_timeline.json
+//   Downloaded 3 files for Tweet 1050118621198921728.
+//   Downloaded 1 files for Tweet 1049430871513821184.
+//   --------------------------------------------------------
+//   Downloaded 214 files, skipped 0, 0 failed.
+//
+// Photos are fetched at their original size.  Videos and GIFs are fetched as
+// the highest bitrate MP4.  Files are named after the SHA-256 of their
+// content, like media/3f/3f9a...e1.jpg, so media shared by several Tweets is
+// stored once.
+//
+// media/manifest.ndjson maps Tweets to files, one JSON object per line:
+//   {"tweet_id":"1050118621198921728","media_id":"1050118597241090048",
+//    "type":"photo","url":"https://pbs.twimg.com/media/Dp...jpg",
+//    "file":"3f/3f9a...e1.jpg","sha256":"3f9a...e1","size":183215}
+//
+// Media already in the manifest is skipped, so the command can be run
+// again as the archive grows, or to retry failed downloads.  Retweets are
+// stored with the media of the original Tweet.
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/archive"
+	"github.com/kurrik/twittergo-examples/render"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const MANIFEST = "manifest.ndjson"
+
+type Args struct {
+	Dir    string
+	Inputs []string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Dir, "dir", "media", "Directory to store media and the manifest in")
+	flag.Parse()
+	a.Inputs = flag.Args()
+	return a
+}
+
+// A line of the manifest.
+type ManifestEntry struct {
+	TweetId string `json:"tweet_id"`
+	MediaId string `json:"media_id"`
+	Type    string `json:"type"`
+	URL     string `json:"url"`
+	File    string `json:"file"`
+	SHA256  string `json:"sha256"`
+	Size    int64  `json:"size"`
+}
+
+type Manifest struct {
+	dir string
+	out *os.File
+	// Downloaded files by URL.
+	files map[string]*ManifestEntry
+	// Entries already recorded, by Tweet ID and URL.
+	recorded map[string]bool
+}
+
+// Reads the manifest in dir and opens it for appending.  A partial last line,
+// left by an interrupted run, is dropped.
+func OpenManifest(dir string) (m *Manifest, err error) {
+	var data []byte
+	m = &Manifest{
+		dir:      dir,
+		files:    map[string]*ManifestEntry{},
+		recorded: map[string]bool{},
+	}
+	p := filepath.Join(dir, MANIFEST)
+	if data, err = ioutil.ReadFile(p); err != nil && !os.IsNotExist(err) {
+		return
+	}
+	if i := strings.LastIndex(string(data), "\n"); i+1 < len(data) {
+		data = data[:i+1]
+		if err = os.Truncate(p, int64(len(data))); err != nil {
+			return
+		}
+	}
+	for n, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		entry := &ManifestEntry{}
+		if err = json.Unmarshal([]byte(line), entry); err != nil {
+			err = fmt.Errorf("%v line %v: %v", p, n+1, err)
+			return
+		}
+		m.recorded[entry.TweetId+" "+entry.URL] = true
+		// Files deleted since are downloaded again.
+		if _, err := os.Stat(filepath.Join(dir, filepath.FromSlash(entry.File))); err == nil {
+			m.files[entry.URL] = entry
+		}
+	}
+	m.out, err = os.OpenFile(p, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	return
+}
+
+func (m *Manifest) Close() error {
+	return m.out.Close()
+}
+
+func (m *Manifest) Record(entry *ManifestEntry) (err error) {
+	var text []byte
+	key := entry.TweetId + " " + entry.URL
+	if m.recorded[key] {
+		return
+	}
+	if text, err = json.Marshal(entry); err != nil {
+		return
+	}
+	if _, err = fmt.Fprintf(m.out, "%s\n", text); err == nil {
+		m.recorded[key] = true
+	}
+	return
+}
+
+// Downloads address into dir, named after the hash of its content.  Returns
+// the path of the file relative to dir.
+func download(dir string, address string) (file string, sum string, size int64, err error) {
+	var (
+		u    *url.URL
+		resp *http.Response
+		tmp  *os.File
+	)
+	if u, err = url.Parse(address); err != nil {
+		return
+	}
+	ext := path.Ext(u.Path)
+	if resp, err = http.Get(address); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		err = fmt.Errorf("HTTP status %v", resp.Status)
+		return
+	}
+	if tmp, err = ioutil.TempFile(dir, "download"); err != nil {
+		return
+	}
+	defer os.Remove(tmp.Name())
+	hash := sha256.New()
+	size, err = io.Copy(io.MultiWriter(tmp, hash), resp.Body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return
+	}
+	sum = hex.EncodeToString(hash.Sum(nil))
+	file = path.Join(sum[:2], sum+ext)
+	dest := filepath.Join(dir, filepath.FromSlash(file))
+	if err = os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return
+	}
+	err = os.Rename(tmp.Name(), dest)
+	return
+}
+
+// Returns the address to download a media item from.
+func mediaURL(m render.Media) string {
+	if m.Video != "" {
+		return m.Video
+	}
+	if m.Image == "" {
+		return ""
+	}
+	// Without a size, photos are served scaled down.
+	return m.Image + "?name=orig"
+}
+
+func main() {
+	var (
+		err      error
+		args     *Args
+		manifest *Manifest
+		fetched  int
+		skipped  int
+		failed   int
+	)
+	args = parseArgs()
+	if len(args.Inputs) == 0 {
+		fmt.Fprintf(os.Stderr, "Pass the archives to download media for as arguments.\n")
+		flag.PrintDefaults()
+		os.Exit(1)
+	}
+	if err = os.MkdirAll(args.Dir, 0755); err != nil {
+		fmt.Printf("Could not create %v: %v\n", args.Dir, err)
+		os.Exit(1)
+	}
+	if manifest, err = OpenManifest(args.Dir); err != nil {
+		fmt.Printf("Could not read manifest: %v\n", err)
+		os.Exit(1)
+	}
+	defer manifest.Close()
+	for _, input := range args.Inputs {
+		err = archive.ReadFile(input, func(tweet twittergo.Tweet) error {
+			original := tweet
+			if rt, ok := tweet["retweeted_status"].(map[string]interface{}); ok {
+				original = twittergo.Tweet(rt)
+			}
+			count := 0
+			for _, m := range render.AllMedia(original) {
+				address := mediaURL(m)
+				if address == "" {
+					continue
+				}
+				entry, ok := manifest.files[address]
+				if ok {
+					skipped++
+				} else {
+					file, sum, size, err := download(args.Dir, address)
+					if err != nil {
+						fmt.Printf("Could not download %v for Tweet %v: %v\n", address, tweet.IdStr(), err)
+						failed++
+						continue
+					}
+					entry = &ManifestEntry{URL: address, File: file, SHA256: sum, Size: size}
+					manifest.files[address] = entry
+					fetched++
+					count++
+				}
+				record := *entry
+				record.TweetId = tweet.IdStr()
+				record.MediaId = m.Id
+				record.Type = m.Type
+				if err := manifest.Record(&record); err != nil {
+					return fmt.Errorf("Could not write manifest: %v", err)
+				}
+			}
+			if count > 0 {
+				fmt.Printf("Downloaded %v files for Tweet %v.\n", count, tweet.IdStr())
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("Could not read %v: %v\n", input, err)
+			os.Exit(1)
+		}
+	}
+	fmt.Printf("--------------------------------------------------------\n")
+	fmt.Printf("Downloaded %v files, skipped %v, %v failed.\n", fetched, skipped, failed)
+}
@@ -0,0 +1,157 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaupload
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sort"
+)
+
+// A multipart/form-data body which is written while it is sent, so the
+// media is read straight from its source instead of being held in memory:
+//
+//	file, _ := os.Open("photo.jpg")
+//	form := &mediaupload.Form{
+//	    Params: map[string]string{"media_category": "tweet_image"},
+//	    Field:  "media", FileName: "photo.jpg",
+//	    Media:  file, Size: info.Size(),
+//	}
+//	req, err := form.Request(ctx, mediaupload.UPLOADURL)
+//	resp, err := client.SendRequest(req)
+//
+// OAuth signatures only cover form encoded bodies, so signing never reads
+// a multipart body and the stream is only consumed once, by the transport.
+type Form struct {
+	// Plain fields, sent first.
+	Params map[string]string
+	// Name of the media part.  No media is sent if Media is nil.
+	Field string
+	// Sent as the file name of the media part if not blank.  The media is
+	// sent as a plain field otherwise, as the chunked commands want it.
+	FileName string
+	Media    io.Reader
+	// Bytes Media holds, or -1 if unknown, which sends the body chunked.
+	Size int64
+}
+
+// Writes the form to mp and closes it.
+func (f *Form) write(ctx context.Context, mp *multipart.Writer) (err error) {
+	var part io.Writer
+	keys := make([]string, 0, len(f.Params))
+	for key := range f.Params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		if err = mp.WriteField(key, f.Params[key]); err != nil {
+			return
+		}
+	}
+	if f.Media != nil {
+		if f.FileName != "" {
+			part, err = mp.CreateFormFile(f.Field, f.FileName)
+		} else {
+			part, err = mp.CreateFormField(f.Field)
+		}
+		if err != nil {
+			return
+		}
+		if _, err = io.Copy(part, &contextReader{ctx, f.Media}); err != nil {
+			return
+		}
+	}
+	return mp.Close()
+}
+
+// Returns the length of the body with boundary, or -1 if the size of the
+// media is unknown.
+func (f *Form) length(boundary string) int64 {
+	if f.Media != nil && f.Size < 0 {
+		return -1
+	}
+	counter := &countingWriter{}
+	mp := multipart.NewWriter(counter)
+	mp.SetBoundary(boundary)
+	// Everything but the media itself, which is empty here.
+	(&Form{Params: f.Params, Field: f.Field, FileName: f.FileName, Media: emptyReader(f.Media)}).write(context.Background(), mp)
+	if f.Media != nil {
+		return counter.n + f.Size
+	}
+	return counter.n
+}
+
+// Returns a POST request to url which streams the form.  Cancelling ctx
+// stops both the request and the copying of the media.  The body has to
+// be sent or closed, or the goroutine writing it waits forever.
+func (f *Form) Request(ctx context.Context, url string) (req *http.Request, err error) {
+	reader, writer := io.Pipe()
+	mp := multipart.NewWriter(writer)
+	if req, err = http.NewRequestWithContext(ctx, "POST", url, reader); err != nil {
+		reader.Close()
+		return
+	}
+	req.Header.Set("Content-Type", mp.FormDataContentType())
+	if req.ContentLength = f.length(mp.Boundary()); req.ContentLength < 0 {
+		req.ContentLength = 0
+	}
+	done := make(chan struct{})
+	go func() {
+		// A nil error gives the reader io.EOF.
+		writer.CloseWithError(f.write(ctx, mp))
+		close(done)
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			reader.CloseWithError(ctx.Err())
+		case <-done:
+		}
+	}()
+	return
+}
+
+// Stops reading once its context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r *contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+type countingWriter struct {
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.n += int64(len(p))
+	return len(p), nil
+}
+
+// Stands in for media when measuring the form, keeping whether there is
+// any.
+func emptyReader(media io.Reader) io.Reader {
+	if media == nil {
+		return nil
+	}
+	return &io.LimitedReader{R: media, N: 0}
+}
@@ -0,0 +1,179 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaupload
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Largest segment read when looking for EXIF tags.
+const MAXEXIF = 64 * 1024
+
+// EXIF tags which matter before uploading.
+const (
+	orientationTag = 0x0112
+	gpsTag         = 0x8825
+)
+
+// Metadata found in a JPEG or PNG file.
+type Metadata struct {
+	// Kinds found, like EXIF, XMP or text.
+	Kinds []string
+	// EXIF orientation from 1 to 8, 1 meaning the pixels are stored
+	// upright.
+	Orientation int
+	// Whether the EXIF holds a GPS location.
+	GPS bool
+}
+
+func (m *Metadata) add(kind string) {
+	for _, k := range m.Kinds {
+		if k == kind {
+			return
+		}
+	}
+	m.Kinds = append(m.Kinds, kind)
+}
+
+// Reads the metadata of the image in r, which is size bytes long.  Other
+// media types have none.
+func ReadMetadata(r io.ReaderAt, size int64, mediaType string) (m *Metadata, err error) {
+	m = &Metadata{Orientation: 1}
+	switch mediaType {
+	case "image/jpeg":
+		err = jpegMetadata(r, size, m)
+	case "image/png":
+		err = pngMetadata(r, size, m)
+	}
+	return
+}
+
+// Reads the segments of a JPEG up to the image data.
+func jpegMetadata(r io.ReaderAt, size int64, m *Metadata) (err error) {
+	header := make([]byte, 4)
+	for pos := int64(2); pos+4 <= size; {
+		if _, err = r.ReadAt(header, pos); err != nil {
+			return
+		}
+		if header[0] != 0xFF {
+			return fmt.Errorf("No JPEG marker at %v", pos)
+		}
+		marker := header[1]
+		switch {
+		case marker == 0xFF:
+			// Padding before a marker.
+			pos++
+			continue
+		case marker == 0xDA || marker == 0xD9:
+			// Start of the image data, or end of the file.
+			return nil
+		case marker == 0x01 || (marker >= 0xD0 && marker <= 0xD7):
+			// Markers without contents.
+			pos += 2
+			continue
+		}
+		length := int64(binary.BigEndian.Uint16(header[2:4]))
+		if length < 2 || pos+2+length > size {
+			return fmt.Errorf("Segment %X at %v has an invalid length %v", marker, pos, length)
+		}
+		switch marker {
+		case 0xE1:
+			var data []byte
+			if data, err = read(r, box{start: pos + 4, size: length - 2}, MAXEXIF); err != nil {
+				return
+			}
+			if len(data) > 6 && string(data[:6]) == "Exif\x00\x00" {
+				m.add("EXIF")
+				parseExif(data[6:], m)
+			} else if len(data) > 29 && string(data[:29]) == "http://ns.adobe.com/xap/1.0/\x00" {
+				m.add("XMP")
+			}
+		case 0xED:
+			m.add("IPTC")
+		case 0xFE:
+			m.add("comment")
+		}
+		pos += 2 + length
+	}
+	return
+}
+
+// Reads the chunks of a PNG.
+func pngMetadata(r io.ReaderAt, size int64, m *Metadata) (err error) {
+	header := make([]byte, 8)
+	for pos := int64(8); pos+12 <= size; {
+		if _, err = r.ReadAt(header, pos); err != nil {
+			return
+		}
+		length := int64(binary.BigEndian.Uint32(header[:4]))
+		kind := string(header[4:8])
+		if pos+12+length > size {
+			return fmt.Errorf("Chunk %q at %v has an invalid length %v", kind, pos, length)
+		}
+		switch kind {
+		case "eXIf":
+			var data []byte
+			if data, err = read(r, box{start: pos + 8, size: length}, MAXEXIF); err != nil {
+				return
+			}
+			m.add("EXIF")
+			parseExif(data, m)
+		case "tEXt", "zTXt", "iTXt":
+			m.add("text")
+		case "IEND":
+			return nil
+		}
+		pos += 12 + length
+	}
+	return
+}
+
+// Reads the orientation and GPS pointer from the first IFD of TIFF
+// formatted EXIF data.
+func parseExif(data []byte, m *Metadata) {
+	var order binary.ByteOrder
+	if len(data) < 8 {
+		return
+	}
+	switch string(data[:4]) {
+	case "II*\x00":
+		order = binary.LittleEndian
+	case "MM\x00*":
+		order = binary.BigEndian
+	default:
+		return
+	}
+	ifd := int(order.Uint32(data[4:8]))
+	if ifd < 8 || ifd+2 > len(data) {
+		return
+	}
+	entries := int(order.Uint16(data[ifd:]))
+	for i := 0; i < entries; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(data) {
+			return
+		}
+		switch order.Uint16(data[entry:]) {
+		case orientationTag:
+			if o := int(order.Uint16(data[entry+8:])); o >= 1 && o <= 8 {
+				m.Orientation = o
+			}
+		case gpsTag:
+			m.GPS = true
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaupload
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// What the headers of an MP4 or QuickTime file say about its video track.
+type Movie struct {
+	Duration  time.Duration
+	Width     int
+	Height    int
+	Frames    int64
+	FrameRate float64
+}
+
+// An ISO base media file box, from its header.
+type box struct {
+	kind string
+	// Offset and size of the contents, after the header.
+	start int64
+	size  int64
+}
+
+// Reads the boxes within [start, end) of r.
+func readBoxes(r io.ReaderAt, start int64, end int64) (boxes []box, err error) {
+	header := make([]byte, 16)
+	for pos := start; pos+8 <= end; {
+		if _, err = r.ReadAt(header[:8], pos); err != nil {
+			return
+		}
+		size := int64(binary.BigEndian.Uint32(header[:4]))
+		kind := string(header[4:8])
+		headerSize := int64(8)
+		switch size {
+		case 0:
+			// Extends to the end.
+			size = end - pos
+		case 1:
+			// 64 bit size after the type.
+			if _, err = r.ReadAt(header[8:16], pos+8); err != nil {
+				return
+			}
+			size = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if size < headerSize || pos+size > end {
+			return nil, fmt.Errorf("Box %q at %v has an invalid size %v", kind, pos, size)
+		}
+		boxes = append(boxes, box{kind: kind, start: pos + headerSize, size: size - headerSize})
+		pos += size
+	}
+	return
+}
+
+func find(boxes []box, kind string) (box, bool) {
+	for _, b := range boxes {
+		if b.kind == kind {
+			return b, true
+		}
+	}
+	return box{}, false
+}
+
+// Reads the contents of b, up to max bytes.
+func read(r io.ReaderAt, b box, max int64) (data []byte, err error) {
+	size := b.size
+	if size > max {
+		size = max
+	}
+	data = make([]byte, size)
+	_, err = r.ReadAt(data, b.start)
+	return
+}
+
+// Reads the boxes nested in b along path, like "mdia", "minf", "stbl".
+func descend(r io.ReaderAt, b box, path ...string) (found box, err error) {
+	var (
+		children []box
+		ok       bool
+	)
+	found = b
+	for _, kind := range path {
+		if children, err = readBoxes(r, found.start, found.start+found.size); err != nil {
+			return
+		}
+		if found, ok = find(children, kind); !ok {
+			return found, fmt.Errorf("No %q box", kind)
+		}
+	}
+	return
+}
+
+// Returns the duration in an mdhd box, in its timescale.
+func mediaHeader(data []byte) (timescale uint32, duration uint64, err error) {
+	if len(data) < 4 {
+		return 0, 0, fmt.Errorf("Short mdhd box")
+	}
+	if data[0] == 1 {
+		// Version 1 has 64 bit times.
+		if len(data) < 32 {
+			return 0, 0, fmt.Errorf("Short mdhd box")
+		}
+		return binary.BigEndian.Uint32(data[20:24]), binary.BigEndian.Uint64(data[24:32]), nil
+	}
+	if len(data) < 20 {
+		return 0, 0, fmt.Errorf("Short mdhd box")
+	}
+	return binary.BigEndian.Uint32(data[12:16]), uint64(binary.BigEndian.Uint32(data[16:20])), nil
+}
+
+// Returns the width and height in a tkhd box, as the track is shown.
+func trackSize(data []byte) (width int, height int) {
+	// The size is the last 8 bytes, as 16.16 fixed point numbers.
+	if len(data) < 8 {
+		return
+	}
+	end := len(data)
+	width = int(binary.BigEndian.Uint32(data[end-8:end-4]) >> 16)
+	height = int(binary.BigEndian.Uint32(data[end-4:]) >> 16)
+	// Phones record portrait video as landscape frames with a matrix which
+	// turns them by 90 or 270 degrees.  The matrix follows the times, which
+	// are 64 bit in version 1.
+	matrix := 40
+	if data[0] == 1 {
+		matrix = 52
+	}
+	if len(data) < matrix+36 {
+		return
+	}
+	a := int32(binary.BigEndian.Uint32(data[matrix : matrix+4]))
+	b := int32(binary.BigEndian.Uint32(data[matrix+4 : matrix+8]))
+	c := int32(binary.BigEndian.Uint32(data[matrix+12 : matrix+16]))
+	d := int32(binary.BigEndian.Uint32(data[matrix+16 : matrix+20]))
+	if a == 0 && d == 0 && b != 0 && c != 0 {
+		width, height = height, width
+	}
+	return
+}
+
+// Returns the number of samples in an stts box.
+func sampleCount(data []byte) (count int64) {
+	if len(data) < 8 {
+		return
+	}
+	entries := int(binary.BigEndian.Uint32(data[4:8]))
+	for i := 0; i < entries && 8+i*8+8 <= len(data); i++ {
+		count += int64(binary.BigEndian.Uint32(data[8+i*8 : 12+i*8]))
+	}
+	return
+}
+
+// Parses the headers of the MP4 in r, which is size bytes long, and
+// describes its first video track.
+func ParseMovie(r io.ReaderAt, size int64) (movie *Movie, err error) {
+	var (
+		top    []box
+		tracks []box
+		moov   box
+		ok     bool
+	)
+	if top, err = readBoxes(r, 0, size); err != nil {
+		return
+	}
+	if _, ok = find(top, "ftyp"); !ok {
+		return nil, fmt.Errorf("Not an MP4 file, no ftyp box")
+	}
+	if moov, ok = find(top, "moov"); !ok {
+		return nil, fmt.Errorf("No moov box, the file may be truncated")
+	}
+	if tracks, err = readBoxes(r, moov.start, moov.start+moov.size); err != nil {
+		return
+	}
+	for _, trak := range tracks {
+		var (
+			hdlr, mdhd, tkhd, stts box
+			data                   []byte
+		)
+		if trak.kind != "trak" {
+			continue
+		}
+		if hdlr, err = descend(r, trak, "mdia", "hdlr"); err != nil {
+			return
+		}
+		if data, err = read(r, hdlr, 12); err != nil {
+			return
+		}
+		if len(data) < 12 || string(data[8:12]) != "vide" {
+			continue
+		}
+		movie = &Movie{}
+		if tkhd, err = descend(r, trak, "tkhd"); err != nil {
+			return
+		}
+		if data, err = read(r, tkhd, 128); err != nil {
+			return
+		}
+		movie.Width, movie.Height = trackSize(data)
+		if mdhd, err = descend(r, trak, "mdia", "mdhd"); err != nil {
+			return
+		}
+		if data, err = read(r, mdhd, 32); err != nil {
+			return
+		}
+		timescale, duration, herr := mediaHeader(data)
+		if herr != nil {
+			return nil, herr
+		}
+		if timescale > 0 {
+			movie.Duration = time.Duration(float64(duration) / float64(timescale) * float64(time.Second))
+		}
+		if stts, err = descend(r, trak, "mdia", "minf", "stbl", "stts"); err != nil {
+			return
+		}
+		if data, err = read(r, stts, stts.size); err != nil {
+			return
+		}
+		movie.Frames = sampleCount(data)
+		if movie.Duration > 0 {
+			movie.FrameRate = float64(movie.Frames) / movie.Duration.Seconds()
+		}
+		return movie, nil
+	}
+	return nil, fmt.Errorf("No video track")
+}
@@ -0,0 +1,207 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaupload
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func join(parts ...[]byte) []byte {
+	return bytes.Join(parts, nil)
+}
+
+func testBox(kind string, contents ...[]byte) []byte {
+	body := join(contents...)
+	header := make([]byte, 8)
+	binary.BigEndian.PutUint32(header, uint32(8+len(body)))
+	copy(header[4:], kind)
+	return join(header, body)
+}
+
+// A box with a 64 bit size after its type.
+func testLargeBox(kind string, contents ...[]byte) []byte {
+	body := join(contents...)
+	header := make([]byte, 16)
+	binary.BigEndian.PutUint32(header, 1)
+	copy(header[4:], kind)
+	binary.BigEndian.PutUint64(header[8:], uint64(16+len(body)))
+	return join(header, body)
+}
+
+func testTkhd(width int, height int) []byte {
+	data := make([]byte, 84)
+	binary.BigEndian.PutUint32(data[76:], uint32(width)<<16)
+	binary.BigEndian.PutUint32(data[80:], uint32(height)<<16)
+	return testBox("tkhd", data)
+}
+
+func testMdhd(timescale uint32, duration uint32) []byte {
+	data := make([]byte, 24)
+	binary.BigEndian.PutUint32(data[12:], timescale)
+	binary.BigEndian.PutUint32(data[16:], duration)
+	return testBox("mdhd", data)
+}
+
+func testMdhd64(timescale uint32, duration uint64) []byte {
+	data := make([]byte, 36)
+	data[0] = 1
+	binary.BigEndian.PutUint32(data[20:], timescale)
+	binary.BigEndian.PutUint64(data[24:], duration)
+	return testBox("mdhd", data)
+}
+
+func testTrak(handler string, width int, height int, mdhd []byte, samples ...uint32) []byte {
+	hdlr := make([]byte, 24)
+	copy(hdlr[8:], handler)
+	stts := make([]byte, 8+8*len(samples))
+	binary.BigEndian.PutUint32(stts[4:], uint32(len(samples)))
+	for i, count := range samples {
+		binary.BigEndian.PutUint32(stts[8+i*8:], count)
+		binary.BigEndian.PutUint32(stts[12+i*8:], 1)
+	}
+	return testBox("trak",
+		testTkhd(width, height),
+		testBox("mdia", mdhd, testBox("hdlr", hdlr),
+			testBox("minf", testBox("stbl", testBox("stts", stts)))))
+}
+
+func testFtyp() []byte {
+	return testBox("ftyp", []byte("isom\x00\x00\x02\x00isomiso2mp41"))
+}
+
+func testMovie(traks ...[]byte) []byte {
+	return join(testFtyp(), testBox("moov", traks...))
+}
+
+func TestParseMovie(t *testing.T) {
+	video := testTrak("vide", 1280, 720, testMdhd(600, 6000), 300)
+	audio := testTrak("soun", 0, 0, testMdhd(44100, 441000), 430)
+	tests := []struct {
+		name  string
+		data  []byte
+		movie *Movie
+		err   string
+	}{
+		{
+			"video", testMovie(video),
+			&Movie{Duration: 10 * time.Second, Width: 1280, Height: 720, Frames: 300, FrameRate: 30}, "",
+		},
+		{
+			"several stts entries", testMovie(testTrak("vide", 640, 480, testMdhd(1000, 5000), 100, 50)),
+			&Movie{Duration: 5 * time.Second, Width: 640, Height: 480, Frames: 150, FrameRate: 30}, "",
+		},
+		{
+			"version 1 mdhd", testMovie(testTrak("vide", 1920, 1080, testMdhd64(90000, 900000), 240)),
+			&Movie{Duration: 10 * time.Second, Width: 1920, Height: 1080, Frames: 240, FrameRate: 24}, "",
+		},
+		{
+			"audio track first", testMovie(audio, video),
+			&Movie{Duration: 10 * time.Second, Width: 1280, Height: 720, Frames: 300, FrameRate: 30}, "",
+		},
+		{
+			"64 bit box size", join(testFtyp(), testLargeBox("moov", video)),
+			&Movie{Duration: 10 * time.Second, Width: 1280, Height: 720, Frames: 300, FrameRate: 30}, "",
+		},
+		{
+			"box to the end", join(testFtyp(), []byte{0, 0, 0, 0, 'm', 'o', 'o', 'v'}, video),
+			&Movie{Duration: 10 * time.Second, Width: 1280, Height: 720, Frames: 300, FrameRate: 30}, "",
+		},
+		{"no ftyp", testBox("moov", video), nil, "no ftyp box"},
+		{"no moov", testFtyp(), nil, "No moov box"},
+		{"no video", testMovie(audio), nil, "No video track"},
+		{"no mdhd", testMovie(testBox("trak", testTkhd(1, 1), testBox("mdia", testBox("hdlr", make([]byte, 8), []byte("vide"))))), nil, "No \"mdhd\" box"},
+		{"short box", join(testFtyp(), []byte{0, 0, 0, 4, 'm', 'o', 'o', 'v'}), nil, "invalid size 4"},
+		{"truncated box", testMovie(video)[:100], nil, "invalid size"},
+	}
+	for _, test := range tests {
+		movie, err := ParseMovie(bytes.NewReader(test.data), int64(len(test.data)))
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%v: ParseMovie() = %v, want no error", test.name, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%v: ParseMovie() = %v, want an error about %q", test.name, err, test.err)
+		case test.err == "" && !reflect.DeepEqual(movie, test.movie):
+			t.Errorf("%v: ParseMovie() = %+v, want %+v", test.name, movie, test.movie)
+		}
+	}
+}
+
+// The contents of a tkhd box with the transformation matrix a, b, c, d,
+// in 16.16 fixed point.
+func testTkhdData(version byte, width int, height int, a int32, b int32, c int32, d int32) []byte {
+	matrix, size := 40, 84
+	if version == 1 {
+		matrix, size = 52, 96
+	}
+	data := make([]byte, size)
+	data[0] = version
+	binary.BigEndian.PutUint32(data[matrix:], uint32(a))
+	binary.BigEndian.PutUint32(data[matrix+4:], uint32(b))
+	binary.BigEndian.PutUint32(data[matrix+12:], uint32(c))
+	binary.BigEndian.PutUint32(data[matrix+16:], uint32(d))
+	binary.BigEndian.PutUint32(data[size-8:], uint32(width)<<16)
+	binary.BigEndian.PutUint32(data[size-4:], uint32(height)<<16)
+	return data
+}
+
+func TestTrackSize(t *testing.T) {
+	const one, minus = 1 << 16, -1 << 16
+	tests := []struct {
+		name   string
+		data   []byte
+		width  int
+		height int
+	}{
+		{"no matrix", testTkhd(1280, 720)[8:], 1280, 720},
+		{"identity", testTkhdData(0, 1280, 720, one, 0, 0, one), 1280, 720},
+		{"90 degrees", testTkhdData(0, 1920, 1080, 0, one, minus, 0), 1080, 1920},
+		{"270 degrees", testTkhdData(0, 1280, 720, 0, minus, one, 0), 720, 1280},
+		{"180 degrees", testTkhdData(0, 1280, 720, minus, 0, 0, minus), 1280, 720},
+		{"version 1", testTkhdData(1, 1280, 720, 0, one, minus, 0), 720, 1280},
+		{"short", []byte{0, 0, 0, 0}, 0, 0},
+	}
+	for _, test := range tests {
+		if width, height := trackSize(test.data); width != test.width || height != test.height {
+			t.Errorf("%v: trackSize() = %vx%v, want %vx%v", test.name, width, height, test.width, test.height)
+		}
+	}
+}
+
+func TestMediaHeader(t *testing.T) {
+	tests := []struct {
+		data      []byte
+		timescale uint32
+		duration  uint64
+		err       bool
+	}{
+		{testMdhd(600, 6000)[8:], 600, 6000, false},
+		{testMdhd64(90000, 1<<40)[8:], 90000, 1 << 40, false},
+		{[]byte{0, 0}, 0, 0, true},
+		{testMdhd(600, 6000)[8:18], 0, 0, true},
+		{testMdhd64(90000, 1)[8:30], 0, 0, true},
+	}
+	for _, test := range tests {
+		timescale, duration, err := mediaHeader(test.data)
+		if timescale != test.timescale || duration != test.duration || (err != nil) != test.err {
+			t.Errorf("mediaHeader(% x) = %v, %v, %v, want %v, %v, error %v",
+				test.data, timescale, duration, err, test.timescale, test.duration, test.err)
+		}
+	}
+}
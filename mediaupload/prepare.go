@@ -0,0 +1,355 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaupload
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// JPEG quality used when Options.Quality is 0.
+const DEFAULTQUALITY = 85
+
+// Qualities tried in turn when a JPEG is still over the size limit.
+var fallbackQualities = []int{75, 65, 55}
+
+// How to prepare images.  The zero value keeps them within Twitter's
+// limits and strips their metadata.
+type Options struct {
+	// Longest side in pixels.  0 for the limit of IMAGE.
+	MaxDimension int `json:"max_dimension,omitempty"`
+	// Largest file in bytes.  0 for the limit of IMAGE.
+	MaxBytes int64 `json:"max_bytes,omitempty"`
+	// JPEG quality from 1 to 100.
+	Quality int `json:"quality,omitempty"`
+	// Leave metadata alone unless the image has to be encoded again
+	// anyway, which always drops it.
+	KeepMetadata bool `json:"keep_metadata,omitempty"`
+}
+
+// An image ready to upload.
+type Prepared struct {
+	// The file to upload, a temporary file if anything changed.
+	Path     string
+	Original string
+	// What was found out about Path.
+	Media *Media
+	// What was done to the original, in words.
+	Changes []string
+}
+
+func (p *Prepared) Changed() bool {
+	return p.Path != p.Original
+}
+
+// Removes the temporary file, if there is one.
+func (p *Prepared) Remove() error {
+	if !p.Changed() {
+		return nil
+	}
+	return os.Remove(p.Path)
+}
+
+func (p *Prepared) String() string {
+	if !p.Changed() {
+		return fmt.Sprintf("%v: unchanged", p.Original)
+	}
+	return fmt.Sprintf("%v: %v", p.Original, strings.Join(p.Changes, ", "))
+}
+
+// Names of the formats images are written in.
+var formatNames = map[string]string{
+	"image/jpeg": "JPEG",
+	"image/png":  "PNG",
+	"image/gif":  "GIF",
+}
+
+// Makes the still image at path fit opts and Twitter's limits: scales it
+// down to the largest dimension allowed, strips EXIF and other metadata,
+// which may hold the location a photo was taken, and turns PNGs which are
+// too large into JPEGs.  The image is written to a temporary file only if
+// something had to change.  GIFs, videos and WebP images are left alone.
+func Prepare(path string, opts Options) (prepared *Prepared, err error) {
+	var (
+		media    *Media
+		file     *os.File
+		metadata *Metadata
+		decoded  image.Image
+		data     []byte
+	)
+	if media, err = Detect(path); err != nil {
+		return
+	}
+	prepared = &Prepared{Path: path, Original: path, Media: media}
+	if media.Category != IMAGE || media.MediaType == "image/webp" {
+		return
+	}
+	limits := CategoryLimits[IMAGE]
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 || maxBytes > limits.MaxBytes {
+		maxBytes = limits.MaxBytes
+	}
+	maxDimension := opts.MaxDimension
+	if maxDimension <= 0 || maxDimension > limits.MaxWidth {
+		maxDimension = limits.MaxWidth
+	}
+	quality := opts.Quality
+	if quality <= 0 || quality > 100 {
+		quality = DEFAULTQUALITY
+	}
+	if file, err = os.Open(path); err != nil {
+		return
+	}
+	defer file.Close()
+	if metadata, err = ReadMetadata(file, media.Size, media.MediaType); err != nil {
+		return nil, fmt.Errorf("Could not read metadata of %v: %v", path, err)
+	}
+	resize := media.Width > maxDimension || media.Height > maxDimension
+	strip := !opts.KeepMetadata && len(metadata.Kinds) > 0
+	if !resize && !strip && media.Size <= maxBytes {
+		return
+	}
+
+	if decoded, _, err = image.Decode(io.NewSectionReader(file, 0, media.Size)); err != nil {
+		return nil, fmt.Errorf("Could not decode %v: %v", path, err)
+	}
+	img := toRGBA(decoded)
+	if metadata.Orientation > 1 {
+		// The orientation goes with the rest of the metadata, so the
+		// pixels have to be turned instead.
+		img = orient(img, metadata.Orientation)
+		prepared.Changes = append(prepared.Changes, "turned upright as its EXIF orientation said")
+	}
+	uprightWidth, uprightHeight := img.Bounds().Dx(), img.Bounds().Dy()
+	width, height := uprightWidth, uprightHeight
+	if resize {
+		width, height = fit(width, height, maxDimension)
+		img = shrink(img, width, height)
+	}
+	mediaType := media.MediaType
+	if mediaType == "image/gif" {
+		// Still GIFs lose colors as GIF, and none as PNG.
+		mediaType = "image/png"
+	}
+	if data, err = encode(img, mediaType, quality); err != nil {
+		return
+	}
+	if int64(len(data)) > maxBytes && mediaType == "image/png" {
+		mediaType = "image/jpeg"
+		if data, err = encode(img, mediaType, quality); err != nil {
+			return
+		}
+	}
+	for _, q := range fallbackQualities {
+		if int64(len(data)) <= maxBytes {
+			break
+		}
+		if q >= quality {
+			continue
+		}
+		quality = q
+		if data, err = encode(img, mediaType, quality); err != nil {
+			return
+		}
+	}
+	for int64(len(data)) > maxBytes {
+		// Give up some pixels, a quarter of each side at a time.
+		if width*3/4 < limits.MinWidth || height*3/4 < limits.MinHeight {
+			return nil, fmt.Errorf("Could not get %v under %v bytes", path, maxBytes)
+		}
+		width, height = width*3/4, height*3/4
+		img = shrink(img, width, height)
+		if data, err = encode(img, mediaType, quality); err != nil {
+			return
+		}
+	}
+
+	if width != uprightWidth || height != uprightHeight {
+		prepared.Changes = append(prepared.Changes, fmt.Sprintf("resized from %vx%v to %vx%v", uprightWidth, uprightHeight, width, height))
+	}
+	if mediaType != media.MediaType {
+		prepared.Changes = append(prepared.Changes, fmt.Sprintf("converted from %v to %v", formatNames[media.MediaType], formatNames[mediaType]))
+	}
+	if mediaType == "image/jpeg" {
+		prepared.Changes = append(prepared.Changes, fmt.Sprintf("saved at JPEG quality %v", quality))
+	}
+	if len(metadata.Kinds) > 0 {
+		removed := fmt.Sprintf("removed %v metadata", strings.Join(metadata.Kinds, ", "))
+		if metadata.GPS {
+			removed += " including its GPS location"
+		}
+		prepared.Changes = append(prepared.Changes, removed)
+	}
+	prepared.Changes = append(prepared.Changes, fmt.Sprintf("%v bytes, was %v", len(data), media.Size))
+	if prepared.Path, err = writeTemp(data, strings.ToLower(formatNames[mediaType])); err != nil {
+		return nil, err
+	}
+	if prepared.Media, err = Detect(prepared.Path); err != nil {
+		prepared.Remove()
+		return nil, err
+	}
+	return
+}
+
+func writeTemp(data []byte, ext string) (path string, err error) {
+	var file *os.File
+	if file, err = ioutil.TempFile("", "prepared-*."+ext); err != nil {
+		return
+	}
+	path = file.Name()
+	if _, err = file.Write(data); err == nil {
+		err = file.Close()
+	} else {
+		file.Close()
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return
+}
+
+func encode(img *image.RGBA, mediaType string, quality int) ([]byte, error) {
+	var (
+		buf = &bytes.Buffer{}
+		err error
+	)
+	if mediaType == "image/jpeg" {
+		err = jpeg.Encode(buf, flatten(img), &jpeg.Options{Quality: quality})
+	} else {
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(buf, img)
+	}
+	return buf.Bytes(), err
+}
+
+// Returns the size of a width by height image scaled down so its longest
+// side is max.
+func fit(width int, height int, max int) (int, int) {
+	if width >= height {
+		return max, maxInt(1, height*max/width)
+	}
+	return maxInt(1, width*max/height), max
+}
+
+func maxInt(a int, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, b.Min, draw.Src)
+	return rgba
+}
+
+// Puts the image on white, since JPEGs have no transparency.
+func flatten(img *image.RGBA) *image.RGBA {
+	if img.Opaque() {
+		return img
+	}
+	flat := image.NewRGBA(img.Bounds())
+	for i := 0; i+3 < len(img.Pix); i += 4 {
+		// Colors are premultiplied, so white shows through by 255 - alpha.
+		under := 255 - img.Pix[i+3]
+		flat.Pix[i] = img.Pix[i] + under
+		flat.Pix[i+1] = img.Pix[i+1] + under
+		flat.Pix[i+2] = img.Pix[i+2] + under
+		flat.Pix[i+3] = 255
+	}
+	return flat
+}
+
+// Scales img down to width by height, averaging the pixels which end up
+// in each new one.
+func shrink(img *image.RGBA, width int, height int) *image.RGBA {
+	srcWidth, srcHeight := img.Bounds().Dx(), img.Bounds().Dy()
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcHeight/height, (y+1)*srcHeight/height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcWidth/width, (x+1)*srcWidth/width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var sum [4]uint64
+			for sy := y0; sy < y1; sy++ {
+				i := img.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					sum[0] += uint64(img.Pix[i])
+					sum[1] += uint64(img.Pix[i+1])
+					sum[2] += uint64(img.Pix[i+2])
+					sum[3] += uint64(img.Pix[i+3])
+					i += 4
+				}
+			}
+			n := uint64((x1 - x0) * (y1 - y0))
+			d := dst.PixOffset(x, y)
+			for c := 0; c < 4; c++ {
+				dst.Pix[d+c] = uint8(sum[c] / n)
+			}
+		}
+	}
+	return dst
+}
+
+// Turns img so it looks the way the EXIF orientation says it should.
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	w, h := img.Bounds().Dx(), img.Bounds().Dy()
+	// Where the pixel at x, y goes.
+	var to func(x, y int) (int, int)
+	switch orientation {
+	case 2:
+		to = func(x, y int) (int, int) { return w - 1 - x, y }
+	case 3:
+		to = func(x, y int) (int, int) { return w - 1 - x, h - 1 - y }
+	case 4:
+		to = func(x, y int) (int, int) { return x, h - 1 - y }
+	case 5:
+		to = func(x, y int) (int, int) { return y, x }
+	case 6:
+		to = func(x, y int) (int, int) { return h - 1 - y, x }
+	case 7:
+		to = func(x, y int) (int, int) { return h - 1 - y, w - 1 - x }
+	case 8:
+		to = func(x, y int) (int, int) { return y, w - 1 - x }
+	default:
+		return img
+	}
+	rect := image.Rect(0, 0, w, h)
+	if orientation >= 5 {
+		rect = image.Rect(0, 0, h, w)
+	}
+	dst := image.NewRGBA(rect)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := to(x, y)
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], img.Pix[img.PixOffset(x, y):img.PixOffset(x, y)+4])
+		}
+	}
+	return dst
+}
@@ -0,0 +1,226 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaupload
+
+import (
+	"fmt"
+	"image"
+	"image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Values of media_category.
+const (
+	IMAGE = "tweet_image"
+	GIF   = "tweet_gif"
+	VIDEO = "tweet_video"
+	// For media sent in direct messages.
+	DMIMAGE = "dm_image"
+	DMGIF   = "dm_gif"
+	DMVIDEO = "dm_video"
+)
+
+// The direct message category of each Tweet category.
+var DirectMessageCategories = map[string]string{
+	IMAGE: DMIMAGE,
+	GIF:   DMGIF,
+	VIDEO: DMVIDEO,
+}
+
+// What Twitter accepts for each media_category.
+type Limits struct {
+	MaxBytes    int64
+	MinWidth    int
+	MinHeight   int
+	MaxWidth    int
+	MaxHeight   int
+	MaxFrames   int64
+	MinDuration time.Duration
+	MaxDuration time.Duration
+	MaxFPS      float64
+	// Widest and tallest aspect ratios, as width over height.
+	MinAspect float64
+	MaxAspect float64
+	// Largest size of media taller than it is wide, if it is not
+	// MaxWidth by MaxHeight.
+	MaxPortraitWidth  int
+	MaxPortraitHeight int
+}
+
+var CategoryLimits = map[string]Limits{
+	IMAGE: {
+		MaxBytes: 5 * 1024 * 1024,
+		MinWidth: 4, MinHeight: 4,
+		MaxWidth: 8192, MaxHeight: 8192,
+	},
+	GIF: {
+		MaxBytes: 15 * 1024 * 1024,
+		MinWidth: 4, MinHeight: 4,
+		MaxWidth: 1280, MaxHeight: 1080,
+		MaxFrames: 350,
+	},
+	VIDEO: {
+		MaxBytes: 512 * 1024 * 1024,
+		MinWidth: 32, MinHeight: 32,
+		MaxWidth: 1920, MaxHeight: 1200,
+		MaxPortraitWidth: 1200, MaxPortraitHeight: 1900,
+		MinDuration: 500 * time.Millisecond,
+		MaxDuration: 140 * time.Second,
+		MaxFPS:      60,
+		MinAspect:   1.0 / 3,
+		MaxAspect:   3,
+	},
+}
+
+func init() {
+	// Direct message media has the same limits as in Tweets.
+	for category, dm := range DirectMessageCategories {
+		CategoryLimits[dm] = CategoryLimits[category]
+	}
+}
+
+// What was found out about a file before uploading it.
+type Media struct {
+	Path      string
+	Size      int64
+	MediaType string
+	Category  string
+	Width     int
+	Height    int
+	// Frames of GIFs and videos.
+	Frames int64
+	// Only for videos.
+	Duration  time.Duration
+	FrameRate float64
+}
+
+func (m *Media) String() string {
+	s := fmt.Sprintf("%v, %v, %v bytes, %vx%v", m.MediaType, m.Category, m.Size, m.Width, m.Height)
+	if m.Category == GIF || m.Category == DMGIF {
+		s += fmt.Sprintf(", %v frames", m.Frames)
+	}
+	if m.Category == VIDEO || m.Category == DMVIDEO {
+		s += fmt.Sprintf(", %v at %.2f fps", m.Duration, m.FrameRate)
+	}
+	return s
+}
+
+// Finds out the type of the file at path from its contents, and reads its
+// dimensions, and the length of videos.
+func Detect(path string) (m *Media, err error) {
+	var (
+		file *os.File
+		info os.FileInfo
+		head = make([]byte, 512)
+		n    int
+	)
+	if file, err = os.Open(path); err != nil {
+		return
+	}
+	defer file.Close()
+	if info, err = file.Stat(); err != nil {
+		return
+	}
+	if n, err = file.ReadAt(head, 0); err != nil && n == 0 {
+		return nil, fmt.Errorf("Could not read %v: %v", path, err)
+	}
+	err = nil
+	m = &Media{Path: path, Size: info.Size(), MediaType: http.DetectContentType(head[:n])}
+	if m.MediaType == "application/octet-stream" && n >= 12 && string(head[4:8]) == "ftyp" {
+		// DetectContentType only knows some of the MP4 brands.
+		m.MediaType = "video/mp4"
+	}
+	switch m.MediaType {
+	case "image/jpeg", "image/png", "image/webp":
+		m.Category = IMAGE
+		var config image.Config
+		if config, _, err = image.DecodeConfig(file); err == nil {
+			m.Width, m.Height = config.Width, config.Height
+		} else if m.MediaType == "image/webp" {
+			// No WebP decoder in the standard library.
+			err = nil
+		}
+	case "image/gif":
+		var g *gif.GIF
+		if g, err = gif.DecodeAll(file); err != nil {
+			break
+		}
+		m.Width, m.Height = g.Config.Width, g.Config.Height
+		m.Frames = int64(len(g.Image))
+		if m.Category = IMAGE; m.Frames > 1 {
+			m.Category = GIF
+		}
+	case "video/mp4", "video/quicktime":
+		var movie *Movie
+		m.Category = VIDEO
+		if movie, err = ParseMovie(file, info.Size()); err != nil {
+			break
+		}
+		m.Width, m.Height = movie.Width, movie.Height
+		m.Frames = movie.Frames
+		m.Duration = movie.Duration
+		m.FrameRate = movie.FrameRate
+	default:
+		return m, fmt.Errorf("%v is %v, which Twitter does not accept", path, m.MediaType)
+	}
+	if err != nil {
+		return m, fmt.Errorf("Could not read %v as %v: %v", path, m.MediaType, err)
+	}
+	return
+}
+
+// Checks the media against the limits of its category.
+func (m *Media) Check() error {
+	limits, ok := CategoryLimits[m.Category]
+	if !ok {
+		return fmt.Errorf("Unknown media category %v", m.Category)
+	}
+	if m.Size > limits.MaxBytes {
+		return fmt.Errorf("%v is %v bytes, the limit for %v is %v", m.Path, m.Size, m.Category, limits.MaxBytes)
+	}
+	if m.Width == 0 && m.Height == 0 {
+		// Size unknown, as for WebP.
+		return nil
+	}
+	maxWidth, maxHeight := limits.MaxWidth, limits.MaxHeight
+	if m.Height > m.Width && limits.MaxPortraitHeight > 0 {
+		maxWidth, maxHeight = limits.MaxPortraitWidth, limits.MaxPortraitHeight
+	}
+	if m.Width < limits.MinWidth || m.Height < limits.MinHeight ||
+		m.Width > maxWidth || m.Height > maxHeight {
+		return fmt.Errorf("%v is %vx%v, %v must be between %vx%v and %vx%v", m.Path, m.Width, m.Height,
+			m.Category, limits.MinWidth, limits.MinHeight, maxWidth, maxHeight)
+	}
+	if limits.MaxFrames > 0 && m.Frames > limits.MaxFrames {
+		return fmt.Errorf("%v has %v frames, the limit is %v", m.Path, m.Frames, limits.MaxFrames)
+	}
+	if limits.MaxDuration > 0 && (m.Duration < limits.MinDuration || m.Duration > limits.MaxDuration) {
+		return fmt.Errorf("%v is %v long, videos must be between %v and %v", m.Path, m.Duration, limits.MinDuration, limits.MaxDuration)
+	}
+	if limits.MaxFPS > 0 && m.FrameRate > limits.MaxFPS {
+		return fmt.Errorf("%v has %.2f frames per second, the limit is %v", m.Path, m.FrameRate, limits.MaxFPS)
+	}
+	if limits.MaxAspect > 0 {
+		aspect := float64(m.Width) / float64(m.Height)
+		if aspect < limits.MinAspect || aspect > limits.MaxAspect {
+			return fmt.Errorf("%v has an aspect ratio of %.2f, it must be between 1:3 and 3:1", m.Path, aspect)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,180 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaupload
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/color/palette"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testImage(t *testing.T, format string, frames int) []byte {
+	var (
+		buf bytes.Buffer
+		err error
+	)
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	switch format {
+	case "png":
+		err = png.Encode(&buf, img)
+	case "jpeg":
+		err = jpeg.Encode(&buf, img, nil)
+	case "gif":
+		g := &gif.GIF{}
+		for i := 0; i < frames; i++ {
+			frame := image.NewPaletted(img.Bounds(), palette.Plan9)
+			frame.Set(i, i, color.White)
+			g.Image = append(g.Image, frame)
+			g.Delay = append(g.Delay, 10)
+		}
+		err = gif.EncodeAll(&buf, g)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDetect(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mediaupload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	webp := join([]byte("RIFF\x24\x00\x00\x00WEBPVP8 "), make([]byte, 24))
+	tests := []struct {
+		name      string
+		data      []byte
+		mediaType string
+		category  string
+		width     int
+		height    int
+		frames    int64
+		err       string
+	}{
+		{"png", testImage(t, "png", 1), "image/png", IMAGE, 40, 30, 0, ""},
+		{"jpeg", testImage(t, "jpeg", 1), "image/jpeg", IMAGE, 40, 30, 0, ""},
+		{"still gif", testImage(t, "gif", 1), "image/gif", IMAGE, 40, 30, 1, ""},
+		{"animated gif", testImage(t, "gif", 3), "image/gif", GIF, 40, 30, 3, ""},
+		{"webp", webp, "image/webp", IMAGE, 0, 0, 0, ""},
+		{"mp4", testMovie(testTrak("vide", 1280, 720, testMdhd(600, 6000), 300)), "video/mp4", VIDEO, 1280, 720, 300, ""},
+		{"mp4 without moov", testFtyp(), "video/mp4", VIDEO, 0, 0, 0, "No moov box"},
+		{"text", []byte("hello"), "text/plain; charset=utf-8", "", 0, 0, 0, "Twitter does not accept"},
+		{"broken png", testImage(t, "png", 1)[:20], "image/png", IMAGE, 0, 0, 0, "Could not read"},
+		{"empty", nil, "", "", 0, 0, 0, "Could not read"},
+	}
+	for _, test := range tests {
+		path := filepath.Join(dir, strings.Replace(test.name, " ", "_", -1))
+		if err = ioutil.WriteFile(path, test.data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		m, err := Detect(path)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%v: Detect() = %v, want no error", test.name, err)
+			continue
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%v: Detect() = %v, want an error about %q", test.name, err, test.err)
+			continue
+		}
+		if m == nil {
+			continue
+		}
+		if m.Size != int64(len(test.data)) || m.MediaType != test.mediaType || m.Category != test.category {
+			t.Errorf("%v: Detect() = %v bytes of %v as %q, want %v bytes of %v as %q", test.name,
+				m.Size, m.MediaType, m.Category, len(test.data), test.mediaType, test.category)
+		}
+		if m.Width != test.width || m.Height != test.height || m.Frames != test.frames {
+			t.Errorf("%v: Detect() = %vx%v with %v frames, want %vx%v with %v frames", test.name,
+				m.Width, m.Height, m.Frames, test.width, test.height, test.frames)
+		}
+	}
+	if _, err = Detect(filepath.Join(dir, "missing")); err == nil {
+		t.Errorf("Detect() of a missing file succeeded")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		media Media
+		err   string
+	}{
+		{"image", Media{Category: IMAGE, Size: 1000, Width: 1024, Height: 768}, ""},
+		{"unknown size", Media{Category: IMAGE, Size: 1000}, ""},
+		{"dm image", Media{Category: DMIMAGE, Size: 1000, Width: 1024, Height: 768}, ""},
+		{"unknown category", Media{Category: "tweet_audio"}, "Unknown media category"},
+		{"image too large", Media{Category: IMAGE, Size: 6 * 1024 * 1024, Width: 10, Height: 10}, "the limit for tweet_image"},
+		{"image too small", Media{Category: IMAGE, Size: 100, Width: 2, Height: 2}, "must be between 4x4"},
+		{"image too wide", Media{Category: IMAGE, Size: 100, Width: 9000, Height: 100}, "and 8192x8192"},
+		{"gif", Media{Category: GIF, Size: 1000, Width: 320, Height: 240, Frames: 350}, ""},
+		{"gif frames", Media{Category: GIF, Size: 1000, Width: 320, Height: 240, Frames: 351}, "351 frames"},
+		{
+			"video", Media{Category: VIDEO, Size: 1000, Width: 1280, Height: 720,
+				Duration: 30 * time.Second, FrameRate: 30}, "",
+		},
+		{
+			"video too long", Media{Category: VIDEO, Size: 1000, Width: 1280, Height: 720,
+				Duration: 141 * time.Second, FrameRate: 30}, "videos must be between",
+		},
+		{
+			"video too short", Media{Category: VIDEO, Size: 1000, Width: 1280, Height: 720,
+				Duration: 100 * time.Millisecond, FrameRate: 30}, "videos must be between",
+		},
+		{
+			"video frame rate", Media{Category: VIDEO, Size: 1000, Width: 1280, Height: 720,
+				Duration: 30 * time.Second, FrameRate: 61}, "frames per second",
+		},
+		{
+			"portrait video", Media{Category: VIDEO, Size: 1000, Width: 720, Height: 1280,
+				Duration: 30 * time.Second, FrameRate: 30}, "",
+		},
+		{
+			"largest portrait video", Media{Category: VIDEO, Size: 1000, Width: 1200, Height: 1900,
+				Duration: 30 * time.Second, FrameRate: 30}, "",
+		},
+		{
+			"portrait video too wide", Media{Category: VIDEO, Size: 1000, Width: 1280, Height: 1900,
+				Duration: 30 * time.Second, FrameRate: 30}, "and 1200x1900",
+		},
+		{
+			"landscape video too tall", Media{Category: VIDEO, Size: 1000, Width: 1920, Height: 1280,
+				Duration: 30 * time.Second, FrameRate: 30}, "and 1920x1200",
+		},
+		{
+			"video aspect", Media{Category: VIDEO, Size: 1000, Width: 1600, Height: 400,
+				Duration: 30 * time.Second, FrameRate: 30}, "aspect ratio of 4.00",
+		},
+	}
+	for _, test := range tests {
+		err := test.media.Check()
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%v: Check() = %v, want no error", test.name, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%v: Check() = %v, want an error about %q", test.name, err, test.err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaupload
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/kurrik/twittergo"
+)
+
+// States of processing_info.
+const (
+	PENDING    = "pending"
+	INPROGRESS = "in_progress"
+	FAILED     = "failed"
+	SUCCEEDED  = "succeeded"
+)
+
+// Wait between STATUS requests when the response does not say.
+const DEFAULTCHECKAFTER = time.Duration(5) * time.Second
+
+// Give up on processing after this long.
+const MAXPROCESSING = time.Duration(30) * time.Minute
+
+// The processing_info of an upload.  Videos and GIFs are processed after
+// FINALIZE, and can only be attached to a Tweet once that succeeded.
+type ProcessingInfo map[string]interface{}
+
+func number(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return int64(n), true
+	case int64:
+		return n, true
+	}
+	return 0, false
+}
+
+// Returns the processing_info of a response, or nil if there is none and the
+// media is ready to use.
+func Processing(mediaResp twittergo.MediaResponse) ProcessingInfo {
+	if info, ok := mediaResp["processing_info"].(map[string]interface{}); ok {
+		return ProcessingInfo(info)
+	}
+	return nil
+}
+
+// One of PENDING, INPROGRESS, FAILED or SUCCEEDED.
+func (p ProcessingInfo) State() string {
+	s, _ := p["state"].(string)
+	return s
+}
+
+// Percent done, or -1 if unknown.
+func (p ProcessingInfo) Progress() int {
+	if n, ok := number(p["progress_percent"]); ok {
+		return int(n)
+	}
+	return -1
+}
+
+// How long to wait before asking again.
+func (p ProcessingInfo) CheckAfter() time.Duration {
+	if n, ok := number(p["check_after_secs"]); ok && n > 0 {
+		return time.Duration(n) * time.Second
+	}
+	return DEFAULTCHECKAFTER
+}
+
+// Returns why processing failed.
+func (p ProcessingInfo) Err() error {
+	e, _ := p["error"].(map[string]interface{})
+	code, _ := number(e["code"])
+	name, _ := e["name"].(string)
+	message, _ := e["message"].(string)
+	return fmt.Errorf("Processing failed: %v (code %v: %v)", message, code, name)
+}
+
+// Sends a STATUS request for mediaId.
+func Status(ctx context.Context, client *twittergo.Client, mediaId string) (mediaResp twittergo.MediaResponse, err error) {
+	var (
+		req  *http.Request
+		resp *twittergo.APIResponse
+	)
+	query := url.Values{}
+	query.Set("command", "STATUS")
+	query.Set("media_id", mediaId)
+	if req, err = http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%v?%v", UPLOADURL, query.Encode()), nil); err != nil {
+		return
+	}
+	if resp, err = client.SendRequest(req); err != nil {
+		return
+	}
+	err = resp.Parse(&mediaResp)
+	return
+}
+
+// Polls the status of a finalized upload until processing is done,
+// calling progress with every update.  Returns the last response, or an
+// error if processing failed or ctx is done.
+func Wait(ctx context.Context, client *twittergo.Client, mediaResp twittergo.MediaResponse, progress func(info ProcessingInfo)) (twittergo.MediaResponse, error) {
+	var (
+		err      error
+		mediaId  = MediaId(mediaResp)
+		deadline = time.Now().Add(MAXPROCESSING)
+	)
+	for {
+		info := Processing(mediaResp)
+		if info == nil {
+			return mediaResp, nil
+		}
+		if progress != nil {
+			progress(info)
+		}
+		switch info.State() {
+		case SUCCEEDED:
+			return mediaResp, nil
+		case FAILED:
+			return mediaResp, info.Err()
+		}
+		if time.Now().After(deadline) {
+			return mediaResp, fmt.Errorf("Processing of %v did not finish within %v", mediaId, MAXPROCESSING)
+		}
+		if err = sleep(ctx, info.CheckAfter()); err != nil {
+			return mediaResp, err
+		}
+		if mediaResp, err = Status(ctx, client, mediaId); err != nil {
+			return mediaResp, fmt.Errorf("Problem sending STATUS request: %w", err)
+		}
+	}
+}
@@ -0,0 +1,339 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Uploads media of any size with the chunked INIT, APPEND and FINALIZE
+// commands of media/upload.json, then polls STATUS until Twitter has
+// processed it.
+//
+// The file is read one chunk at a time, and each chunk is retried on its
+// own.  Progress is saved next to the file after every chunk, so an
+// interrupted upload resumes where it stopped when run again:
+//
+//	u := &mediaupload.Upload{Path: "talk.mp4", MediaType: "video/mp4"}
+//	u.Progress = func(sent, total int64) { ... }
+//	mediaResp, err := u.Run(ctx, client)
+//	mediaId := mediaupload.MediaId(mediaResp)
+//
+// Chunks are streamed from the file as they are sent, see Form, so memory
+// use does not grow with the chunk size.  Cancelling ctx stops the upload
+// after the last whole chunk, ready to be resumed.
+package mediaupload
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/kurrik/twittergo"
+)
+
+const (
+	UPLOADURL = "https://upload.twitter.com/1.1/media/upload.json"
+	// Largest chunk a single APPEND accepts.
+	MAXCHUNK = 5 * 1024 * 1024
+	// Chunk size used when Upload.ChunkSize is 0.
+	DEFAULTCHUNK = 1024 * 1024
+	// Attempts per request when Upload.Retries is 0.
+	DEFAULTRETRIES = 3
+	// Wait after the first failed attempt, doubling with every attempt.
+	RETRYWAIT = time.Duration(2) * time.Second
+)
+
+// A file to upload.
+type Upload struct {
+	Path string
+	// MIME type, like video/mp4.  Detected from the contents if blank.
+	MediaType string
+	// One of IMAGE, GIF or VIDEO.  Picked from the contents if blank.
+	Category string
+	// Bytes per APPEND, at most MAXCHUNK.
+	ChunkSize int64
+	// Attempts per request before giving up.
+	Retries int
+	// Where progress is kept.  Defaults to Path plus ".upload.json".
+	StatePath string
+	// Called after every chunk with the bytes sent so far.
+	Progress func(sent int64, total int64)
+	// Called with every STATUS update while Twitter processes the media.
+	Processing func(info ProcessingInfo)
+}
+
+// The progress of an upload, saved after every chunk.
+type State struct {
+	// The file as it was when the upload started.  A changed file starts
+	// over.
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"mod_time"`
+	ChunkSize int64     `json:"chunk_size"`
+	MediaId   string    `json:"media_id"`
+	// When Twitter forgets the media ID, so resuming is pointless.
+	ExpiresAt time.Time `json:"expires_at"`
+	// Indexes of the chunks Twitter has.
+	Segments []int `json:"segments"`
+}
+
+func (s *State) Has(segment int) bool {
+	for _, i := range s.Segments {
+		if i == segment {
+			return true
+		}
+	}
+	return false
+}
+
+func (u *Upload) statePath() string {
+	if u.StatePath != "" {
+		return u.StatePath
+	}
+	return u.Path + ".upload.json"
+}
+
+func (u *Upload) chunkSize() int64 {
+	if u.ChunkSize <= 0 {
+		return DEFAULTCHUNK
+	}
+	if u.ChunkSize > MAXCHUNK {
+		return MAXCHUNK
+	}
+	return u.ChunkSize
+}
+
+// Returns the saved progress if it is for info and can still be resumed.
+func (u *Upload) loadState(info os.FileInfo) (state *State) {
+	var data []byte
+	state = &State{}
+	if data, _ = ioutil.ReadFile(u.statePath()); data == nil {
+		return nil
+	}
+	if json.Unmarshal(data, state) != nil {
+		return nil
+	}
+	if state.Size != info.Size() || !state.ModTime.Equal(info.ModTime()) ||
+		state.ChunkSize != u.chunkSize() || time.Now().After(state.ExpiresAt) {
+		return nil
+	}
+	return state
+}
+
+func (u *Upload) saveState(state *State) (err error) {
+	var data []byte
+	sort.Ints(state.Segments)
+	if data, err = json.MarshalIndent(state, "", "  "); err != nil {
+		return
+	}
+	tmp := u.statePath() + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	return os.Rename(tmp, u.statePath())
+}
+
+// Checks the file against the limits of its category before anything is
+// sent, filling in MediaType and Category.
+func (u *Upload) check() (err error) {
+	var media *Media
+	if media, err = Detect(u.Path); err != nil {
+		return
+	}
+	if u.MediaType == "" {
+		u.MediaType = media.MediaType
+	}
+	if u.Category == "" {
+		u.Category = media.Category
+	}
+	media.Category = u.Category
+	return media.Check()
+}
+
+// Sends one media/upload.json command as a streamed multipart form, with
+// the size bytes of media as the media field if media is not nil.
+func Send(ctx context.Context, client *twittergo.Client, params map[string]string, media io.Reader, size int64) (mediaResp twittergo.MediaResponse, err error) {
+	var (
+		req  *http.Request
+		resp *twittergo.APIResponse
+	)
+	form := &Form{Params: params, Field: "media", Media: media, Size: size}
+	if req, err = form.Request(ctx, UPLOADURL); err != nil {
+		return
+	}
+	if resp, err = client.SendRequest(req); err != nil {
+		req.Body.Close()
+		return
+	}
+	err = resp.Parse(&mediaResp)
+	return
+}
+
+// Waits for d, or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Sends a command with chunk as its media, if not nil, trying again after
+// failures.
+func (u *Upload) send(ctx context.Context, client *twittergo.Client, params map[string]string, chunk *io.SectionReader) (mediaResp twittergo.MediaResponse, err error) {
+	retries := u.Retries
+	if retries <= 0 {
+		retries = DEFAULTRETRIES
+	}
+	wait := RETRYWAIT
+	for attempt := 1; ; attempt++ {
+		if chunk == nil {
+			mediaResp, err = Send(ctx, client, params, nil, 0)
+		} else {
+			// Every attempt reads the chunk from its start.
+			mediaResp, err = Send(ctx, client, params, io.NewSectionReader(chunk, 0, chunk.Size()), chunk.Size())
+		}
+		if err == nil || attempt >= retries || ctx.Err() != nil {
+			return
+		}
+		if rle, ok := err.(twittergo.RateLimitError); ok {
+			wait = rle.Reset.Sub(time.Now()) + time.Second
+		}
+		fmt.Fprintf(os.Stderr, "%v failed, trying again in %v: %v\n", params["command"], wait, err)
+		if err = sleep(ctx, wait); err != nil {
+			return
+		}
+		wait *= 2
+	}
+}
+
+func (u *Upload) init(ctx context.Context, client *twittergo.Client, info os.FileInfo) (state *State, err error) {
+	var mediaResp twittergo.MediaResponse
+	params := map[string]string{
+		"command":     "INIT",
+		"media_type":  u.MediaType,
+		"total_bytes": fmt.Sprintf("%d", info.Size()),
+	}
+	if u.Category != "" {
+		params["media_category"] = u.Category
+	}
+	if mediaResp, err = u.send(ctx, client, params, nil); err != nil {
+		return nil, fmt.Errorf("Problem sending INIT request: %w", err)
+	}
+	state = &State{
+		Size:      info.Size(),
+		ModTime:   info.ModTime(),
+		ChunkSize: u.chunkSize(),
+		MediaId:   MediaId(mediaResp),
+		ExpiresAt: time.Now().Add(expiresAfter(mediaResp)),
+		Segments:  []int{},
+	}
+	return state, u.saveState(state)
+}
+
+// Returns the ID of uploaded media from any media/upload.json response.
+// The numeric media_id is decoded as a float64, which
+// twittergo.MediaResponse.MediaId does not expect and which can not hold
+// every ID exactly, so media_id_string is used instead.
+func MediaId(mediaResp twittergo.MediaResponse) string {
+	id, _ := mediaResp["media_id_string"].(string)
+	return id
+}
+
+// How long an uploaded media ID stays valid, a day unless the response
+// says otherwise.
+func expiresAfter(mediaResp twittergo.MediaResponse) time.Duration {
+	switch secs := mediaResp["expires_after_secs"].(type) {
+	case float64:
+		return time.Duration(secs) * time.Second
+	case int64:
+		return time.Duration(secs) * time.Second
+	}
+	return 24 * time.Hour
+}
+
+// Uploads the file, resuming an earlier attempt if possible, and waits
+// until Twitter has processed it.  The MediaId of the returned response can
+// be attached to a Tweet right away.
+func (u *Upload) Run(ctx context.Context, client *twittergo.Client) (mediaResp twittergo.MediaResponse, err error) {
+	var (
+		file  *os.File
+		info  os.FileInfo
+		state *State
+	)
+	if file, err = os.Open(u.Path); err != nil {
+		return
+	}
+	defer file.Close()
+	if info, err = file.Stat(); err != nil {
+		return
+	}
+	if err = u.check(); err != nil {
+		return
+	}
+	if state = u.loadState(info); state != nil {
+		fmt.Fprintf(os.Stderr, "Resuming upload of %v as %v, %v chunks done.\n", u.Path, state.MediaId, len(state.Segments))
+	} else if state, err = u.init(ctx, client, info); err != nil {
+		return
+	}
+	size := state.ChunkSize
+	segments := int((info.Size() + size - 1) / size)
+	sent := int64(0)
+	for _, i := range state.Segments {
+		if end := int64(i+1) * size; end > info.Size() {
+			sent += info.Size() - int64(i)*size
+		} else {
+			sent += size
+		}
+	}
+	for i := 0; i < segments; i++ {
+		if state.Has(i) {
+			continue
+		}
+		n := size
+		if end := int64(i+1) * size; end > info.Size() {
+			n = info.Size() - int64(i)*size
+		}
+		chunk := io.NewSectionReader(file, int64(i)*size, n)
+		if _, err = u.send(ctx, client, map[string]string{
+			"command":       "APPEND",
+			"media_id":      state.MediaId,
+			"segment_index": fmt.Sprintf("%d", i),
+		}, chunk); err != nil {
+			return mediaResp, fmt.Errorf("Problem sending APPEND request for chunk %v: %w", i, err)
+		}
+		state.Segments = append(state.Segments, i)
+		if err = u.saveState(state); err != nil {
+			return
+		}
+		sent += n
+		if u.Progress != nil {
+			u.Progress(sent, info.Size())
+		}
+	}
+	if mediaResp, err = u.send(ctx, client, map[string]string{
+		"command":  "FINALIZE",
+		"media_id": state.MediaId,
+	}, nil); err != nil {
+		return mediaResp, fmt.Errorf("Problem sending FINALIZE request: %w", err)
+	}
+	// Finalized media can not be appended to, so there is nothing left to
+	// resume.
+	os.Remove(u.statePath())
+	return Wait(ctx, client, mediaResp, u.Processing)
+}
@@ -0,0 +1,42 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mediaupload
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kurrik/twittergo"
+)
+
+func TestMediaId(t *testing.T) {
+	tests := []struct {
+		data string
+		want string
+	}{
+		{`{"media_id": 710511363345354753, "media_id_string": "710511363345354753", "expires_after_secs": 86400}`, "710511363345354753"},
+		{`{"media_id": 710511363345354753}`, ""},
+		{`{}`, ""},
+	}
+	for _, test := range tests {
+		var mediaResp twittergo.MediaResponse
+		if err := json.Unmarshal([]byte(test.data), &mediaResp); err != nil {
+			t.Fatal(err)
+		}
+		if got := MediaId(mediaResp); got != test.want {
+			t.Errorf("MediaId(%v) = %q, want %q", test.data, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"strings"
+	"time"
+
+	"github.com/kurrik/twittergo"
+)
+
+// A Tweet flattened into one row for spreadsheets.  These are the csv and
+// tsv columns of TweetSchema, in order:
+//
+//	id                     ID of the Tweet.
+//	created_at             Creation time in UTC, RFC 3339.
+//	user_id                ID of the author.
+//	user_screen_name       Screen name of the author, without the @.
+//	user_name              Display name of the author.
+//	text                   Full text, from full_text or extended_tweet when
+//	                       present.  HTML entities are left escaped, as
+//	                       returned by the API.
+//	in_reply_to_status_id  ID of the Tweet replied to, if any.
+//	in_reply_to_user_id    ID of the user replied to, if any.
+//	retweeted_status_id    ID of the original Tweet if this is a Retweet.
+//	quoted_status_id       ID of the quoted Tweet, if any.
+//	retweet_count          Retweets at the time the Tweet was fetched.
+//	favorite_count         Likes at the time the Tweet was fetched.
+//	reply_count            Replies, only returned by some endpoints.
+//	quote_count            Quotes, only returned by some endpoints.
+//	lang                   Machine detected BCP 47 language, or "und".
+//	hashtags               Hashtags without the #, space separated.
+//	mentions               Mentioned screen names without the @, space
+//	                       separated.
+//	urls                   Expanded URLs, space separated.
+//	media_urls             https URLs of attached photos and video
+//	                       thumbnails, space separated.
+//	place_id               ID of the tagged place, if any.
+//	place_full_name        Name of the tagged place, like "Manhattan, NY".
+//	place_country_code     ISO 3166-1 alpha-2 code of the tagged place.
+//
+// Missing values are empty strings.  Counts are left as returned and are
+// blank rather than zero when the API did not include them.
+type FlatTweet struct {
+	Id                string `json:"id"`
+	CreatedAt         string `json:"created_at"`
+	UserId            string `json:"user_id"`
+	UserScreenName    string `json:"user_screen_name"`
+	UserName          string `json:"user_name"`
+	Text              string `json:"text"`
+	InReplyToStatusId string `json:"in_reply_to_status_id"`
+	InReplyToUserId   string `json:"in_reply_to_user_id"`
+	RetweetedStatusId string `json:"retweeted_status_id"`
+	QuotedStatusId    string `json:"quoted_status_id"`
+	RetweetCount      string `json:"retweet_count"`
+	FavoriteCount     string `json:"favorite_count"`
+	ReplyCount        string `json:"reply_count"`
+	QuoteCount        string `json:"quote_count"`
+	Lang              string `json:"lang"`
+	Hashtags          string `json:"hashtags"`
+	Mentions          string `json:"mentions"`
+	Urls              string `json:"urls"`
+	MediaUrls         string `json:"media_urls"`
+	PlaceId           string `json:"place_id"`
+	PlaceFullName     string `json:"place_full_name"`
+	PlaceCountryCode  string `json:"place_country_code"`
+}
+
+// Returns the object stored under key, or nil.
+func Object(m map[string]interface{}, key string) map[string]interface{} {
+	obj, _ := m[key].(map[string]interface{})
+	return obj
+}
+
+// Returns the array stored under key, or nil.
+func Array(m map[string]interface{}, key string) []interface{} {
+	arr, _ := m[key].([]interface{})
+	return arr
+}
+
+// Returns the entities and extended_entities of a Tweet, taking them from
+// extended_tweet for streamed Tweets over 140 characters.
+func TweetEntities(tweet twittergo.Tweet) (entities map[string]interface{}, extended map[string]interface{}) {
+	source := map[string]interface{}(tweet)
+	if ext := Object(tweet, "extended_tweet"); ext != nil {
+		source = ext
+	}
+	return Object(source, "entities"), Object(source, "extended_entities")
+}
+
+// Collects field from each object in the entities array named kind.
+func entityValues(entities map[string]interface{}, kind string, field string) (values []string) {
+	for _, item := range Array(entities, kind) {
+		if obj, ok := item.(map[string]interface{}); ok {
+			if val := Value(obj, field); val != "" {
+				values = append(values, val)
+			}
+		}
+	}
+	return
+}
+
+// Flattens a Tweet following the schema documented on FlatTweet.
+func Flatten(tweet twittergo.Tweet) *FlatTweet {
+	var (
+		user     = tweet.User()
+		place    = Object(tweet, "place")
+		entities map[string]interface{}
+		extended map[string]interface{}
+		media    []string
+	)
+	entities, extended = TweetEntities(tweet)
+	if extended != nil {
+		media = entityValues(extended, "media", "media_url_https")
+	} else {
+		media = entityValues(entities, "media", "media_url_https")
+	}
+	flat := &FlatTweet{
+		Id:                tweet.IdStr(),
+		UserId:            user.IdStr(),
+		UserScreenName:    user.ScreenName(),
+		UserName:          user.Name(),
+		Text:              TweetText(tweet),
+		InReplyToStatusId: Value(tweet, "in_reply_to_status_id_str"),
+		InReplyToUserId:   Value(tweet, "in_reply_to_user_id_str"),
+		RetweetedStatusId: Value(Object(tweet, "retweeted_status"), "id_str"),
+		QuotedStatusId:    Value(tweet, "quoted_status_id_str"),
+		RetweetCount:      Value(tweet, "retweet_count"),
+		FavoriteCount:     Value(tweet, "favorite_count"),
+		ReplyCount:        Value(tweet, "reply_count"),
+		QuoteCount:        Value(tweet, "quote_count"),
+		Lang:              Value(tweet, "lang"),
+		Hashtags:          strings.Join(entityValues(entities, "hashtags", "text"), " "),
+		Mentions:          strings.Join(entityValues(entities, "user_mentions", "screen_name"), " "),
+		Urls:              strings.Join(entityValues(entities, "urls", "expanded_url"), " "),
+		MediaUrls:         strings.Join(media, " "),
+		PlaceId:           Value(place, "id"),
+		PlaceFullName:     Value(place, "full_name"),
+		PlaceCountryCode:  Value(place, "country_code"),
+	}
+	if _, ok := tweet["created_at"]; ok {
+		flat.CreatedAt = tweet.CreatedAt().UTC().Format(time.RFC3339)
+	}
+	return flat
+}
+
+// Returns the fields of f in the order of FlatTweetColumns.
+func (f *FlatTweet) Row() []string {
+	return []string{
+		f.Id, f.CreatedAt, f.UserId, f.UserScreenName, f.UserName, f.Text,
+		f.InReplyToStatusId, f.InReplyToUserId, f.RetweetedStatusId,
+		f.QuotedStatusId, f.RetweetCount, f.FavoriteCount, f.ReplyCount,
+		f.QuoteCount, f.Lang, f.Hashtags, f.Mentions, f.Urls, f.MediaUrls,
+		f.PlaceId, f.PlaceFullName, f.PlaceCountryCode,
+	}
+}
+
+// Names of the flattened columns, see FlatTweet.
+var FlatTweetColumns = []string{
+	"id", "created_at", "user_id", "user_screen_name", "user_name", "text",
+	"in_reply_to_status_id", "in_reply_to_user_id", "retweeted_status_id",
+	"quoted_status_id", "retweet_count", "favorite_count", "reply_count",
+	"quote_count", "lang", "hashtags", "mentions", "urls", "media_urls",
+	"place_id", "place_full_name", "place_country_code",
+}
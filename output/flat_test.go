@@ -0,0 +1,96 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/kurrik/twittergo"
+)
+
+func TestValue(t *testing.T) {
+	m := map[string]interface{}{
+		"id_str":   "1050118621198921728",
+		"count":    float64(2487956),
+		"ratio":    0.25,
+		"negative": float64(-3),
+		"verified": true,
+		"null":     nil,
+	}
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"id_str", "1050118621198921728"},
+		{"count", "2487956"},
+		{"ratio", "0.25"},
+		{"negative", "-3"},
+		{"verified", "true"},
+		{"null", ""},
+		{"missing", ""},
+	}
+	for _, test := range tests {
+		if got := Value(m, test.key); got != test.want {
+			t.Errorf("Value(%q) = %q, want %q", test.key, got, test.want)
+		}
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want FlatTweet
+	}{
+		{
+			"counts",
+			`{"id_str": "20", "created_at": "Tue Mar 21 20:50:14 +0000 2006",
+			  "text": "just setting up my twttr", "lang": "en",
+			  "retweet_count": 2487956, "favorite_count": 163000, "reply_count": 0,
+			  "user": {"id_str": "12", "screen_name": "jack", "name": "jack"}}`,
+			FlatTweet{
+				Id: "20", CreatedAt: "2006-03-21T20:50:14Z", UserId: "12", UserScreenName: "jack",
+				UserName: "jack", Text: "just setting up my twttr", RetweetCount: "2487956",
+				FavoriteCount: "163000", ReplyCount: "0", Lang: "en",
+			},
+		},
+		{
+			"extended tweet",
+			`{"id_str": "21", "text": "short…", "in_reply_to_status_id_str": "20",
+			  "user": {"id_str": "13", "screen_name": "kurrik", "name": "Arne"},
+			  "extended_tweet": {"full_text": "the whole text #go @jack",
+			    "entities": {"hashtags": [{"text": "go"}], "user_mentions": [{"screen_name": "jack"}]},
+			    "extended_entities": {"media": [{"media_url_https": "https://pbs.twimg.com/a.jpg"}]}},
+			  "place": {"id": "5a110d312052166f", "full_name": "San Francisco, CA", "country_code": "US"}}`,
+			FlatTweet{
+				Id: "21", UserId: "13", UserScreenName: "kurrik", UserName: "Arne",
+				Text: "the whole text #go @jack", InReplyToStatusId: "20", Hashtags: "go",
+				Mentions: "jack", MediaUrls: "https://pbs.twimg.com/a.jpg", PlaceId: "5a110d312052166f",
+				PlaceFullName: "San Francisco, CA", PlaceCountryCode: "US",
+			},
+		},
+	}
+	for _, test := range tests {
+		var tweet twittergo.Tweet
+		if err := json.Unmarshal([]byte(test.data), &tweet); err != nil {
+			t.Fatalf("%v: %v", test.name, err)
+		}
+		if got := Flatten(tweet); !reflect.DeepEqual(*got, test.want) {
+			t.Errorf("%v: Flatten() = %+v, want %+v", test.name, *got, test.want)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Writes the results of the example commands as text for people to read, or
+// as JSON, NDJSON, CSV, TSV or a Go text/template for other programs.
+//
+// Commands describe each kind of record they print with a Schema and pass
+// every record to a Writer:
+//
+//	w, err := output.NewWriter(os.Stdout, args.Output, args.Template, output.TweetSchema)
+//	for _, tweet := range results.Statuses() {
+//	    w.Write(tweet)
+//	}
+//	w.Close()
+//
+// Templates are executed once per record and followed by a newline, much
+// like `go list -f`.  Records from the twittergo library are maps with
+// accessor methods, so both work:
+//
+//	-output=template -template='{{.IdStr}} {{.User.ScreenName}} {{index . "lang"}}'
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+)
+
+const (
+	Text     = "text"
+	JSON     = "json"
+	NDJSON   = "ndjson"
+	CSV      = "csv"
+	TSV      = "tsv"
+	Template = "template"
+)
+
+// Help text for the -output and -template flags.
+const (
+	FormatUsage   = "Output format: text, json, ndjson, csv, tsv or template"
+	TemplateUsage = "Go text/template applied to each record with -output=template"
+)
+
+// A column of csv and tsv output.
+type Column struct {
+	Name  string
+	Value func(record interface{}) string
+}
+
+// Describes how to write one kind of record.
+type Schema struct {
+	Columns []Column
+	// Optionally returns every column of a record at once, for records
+	// which are costly to convert.  Column values are ignored when set.
+	Row func(record interface{}) []string
+	// Writes the i-th record (counting from 1) for the text format.
+	Text func(w io.Writer, i int, record interface{}) error
+}
+
+// Functions available to templates in addition to the text/template
+// builtins.
+var Funcs = template.FuncMap{
+	"json": func(v interface{}) (string, error) {
+		text, err := json.Marshal(v)
+		return string(text), err
+	},
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+}
+
+// Writes records in a single format.
+type Writer struct {
+	out    io.Writer
+	format string
+	schema *Schema
+	tmpl   *template.Template
+	csv    *csv.Writer
+	count  int
+}
+
+func NewWriter(out io.Writer, format string, tmpl string, schema *Schema) (w *Writer, err error) {
+	w = &Writer{
+		out:    out,
+		format: format,
+		schema: schema,
+	}
+	switch format {
+	case Text, JSON, NDJSON, TSV:
+	case CSV:
+		w.csv = csv.NewWriter(out)
+	case Template:
+		if tmpl == "" {
+			err = fmt.Errorf("Specify a -template to use -output=template")
+			return
+		}
+		if w.tmpl, err = template.New("output").Funcs(Funcs).Parse(tmpl); err != nil {
+			err = fmt.Errorf("Could not parse template: %v", err)
+			return
+		}
+	default:
+		err = fmt.Errorf("Unknown output format %v", format)
+	}
+	return
+}
+
+// Returns the number of records written so far.
+func (w *Writer) Count() int {
+	return w.count
+}
+
+// Picks up after count records written by an earlier run, as when resuming
+// an export into the same file, so that headers are not written again.
+func (w *Writer) Continue(count int) {
+	w.count = count
+}
+
+// Returns true when writing for people rather than programs.
+func (w *Writer) IsText() bool {
+	return w.format == Text
+}
+
+// Prints a message which is not part of the results.  In the text format it
+// is written alongside the results, otherwise it goes to stderr so that the
+// output stays machine readable.
+func (w *Writer) Notef(format string, args ...interface{}) {
+	if w.format == Text {
+		fmt.Fprintf(w.out, format, args...)
+	} else {
+		fmt.Fprintf(os.Stderr, format, args...)
+	}
+}
+
+func (w *Writer) row(record interface{}) []string {
+	if w.schema.Row != nil {
+		return w.schema.Row(record)
+	}
+	row := make([]string, len(w.schema.Columns))
+	for i, col := range w.schema.Columns {
+		row[i] = col.Value(record)
+	}
+	return row
+}
+
+func (w *Writer) header() []string {
+	header := make([]string, len(w.schema.Columns))
+	for i, col := range w.schema.Columns {
+		header[i] = col.Name
+	}
+	return header
+}
+
+// Escapes the characters which would break a tsv row.
+var tsvEscaper = strings.NewReplacer("\\", "\\\\", "\t", "\\t", "\n", "\\n", "\r", "\\r")
+
+func (w *Writer) writeTSV(row []string) (err error) {
+	for i, field := range row {
+		row[i] = tsvEscaper.Replace(field)
+	}
+	_, err = fmt.Fprintf(w.out, "%v\n", strings.Join(row, "\t"))
+	return
+}
+
+func (w *Writer) Write(record interface{}) (err error) {
+	var text []byte
+	w.count++
+	switch w.format {
+	case Text:
+		if w.schema.Text == nil {
+			_, err = fmt.Fprintf(w.out, "%v\n", record)
+			return
+		}
+		err = w.schema.Text(w.out, w.count, record)
+	case JSON:
+		if text, err = json.MarshalIndent(record, "  ", "  "); err != nil {
+			return
+		}
+		sep := ",\n  "
+		if w.count == 1 {
+			sep = "[\n  "
+		}
+		_, err = fmt.Fprintf(w.out, "%v%s", sep, text)
+	case NDJSON:
+		if text, err = json.Marshal(record); err != nil {
+			return
+		}
+		_, err = fmt.Fprintf(w.out, "%s\n", text)
+	case CSV:
+		if w.count == 1 {
+			w.csv.Write(w.header())
+		}
+		if err = w.csv.Write(w.row(record)); err != nil {
+			return
+		}
+		// Flush each row so that long running commands write as they go.
+		w.csv.Flush()
+		err = w.csv.Error()
+	case TSV:
+		if w.count == 1 {
+			if err = w.writeTSV(w.header()); err != nil {
+				return
+			}
+		}
+		err = w.writeTSV(w.row(record))
+	case Template:
+		if err = w.tmpl.Execute(w.out, record); err != nil {
+			return
+		}
+		_, err = fmt.Fprintf(w.out, "\n")
+	}
+	return
+}
+
+// Finishes the output.  Must be called once all records are written.
+func (w *Writer) Close() (err error) {
+	if w.format == JSON {
+		if w.count == 0 {
+			_, err = fmt.Fprintf(w.out, "[]\n")
+		} else {
+			_, err = fmt.Fprintf(w.out, "\n]\n")
+		}
+	}
+	return
+}
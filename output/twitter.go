@@ -0,0 +1,124 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package output
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/kurrik/twittergo"
+)
+
+// Formats the value stored under key, or returns "" if there is none.
+// Numbers are decoded as float64, so they are written out in full rather
+// than in exponent form, which would turn 2487956 into 2.487956e+06.
+func Value(m map[string]interface{}, key string) string {
+	switch val := m[key].(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// Returns the text of a Tweet, preferring full_text which replaces text
+// when requesting tweet_mode=extended, or is found in extended_tweet for
+// streamed Tweets over 140 characters.
+func TweetText(tweet twittergo.Tweet) string {
+	if text := Value(Object(tweet, "extended_tweet"), "full_text"); text != "" {
+		return text
+	}
+	if text := Value(tweet, "full_text"); text != "" {
+		return text
+	}
+	return tweet.Text()
+}
+
+func asTweet(record interface{}) twittergo.Tweet {
+	switch t := record.(type) {
+	case *twittergo.Tweet:
+		return *t
+	case twittergo.Tweet:
+		return t
+	}
+	return twittergo.Tweet{}
+}
+
+func asUser(record interface{}) twittergo.User {
+	switch u := record.(type) {
+	case *twittergo.User:
+		return *u
+	case twittergo.User:
+		return u
+	}
+	return twittergo.User{}
+}
+
+func userColumn(name string, value func(twittergo.User) string) Column {
+	return Column{name, func(record interface{}) string {
+		return value(asUser(record))
+	}}
+}
+
+func userField(key string) func(twittergo.User) string {
+	return func(u twittergo.User) string {
+		return Value(u, key)
+	}
+}
+
+func columnNames(names []string) (columns []Column) {
+	for _, name := range names {
+		columns = append(columns, Column{Name: name})
+	}
+	return
+}
+
+// Writes twittergo.Tweet records.  The csv and tsv formats use the flattened
+// schema documented on FlatTweet.
+var TweetSchema = &Schema{
+	Columns: columnNames(FlatTweetColumns),
+	Row: func(record interface{}) []string {
+		return Flatten(asTweet(record)).Row()
+	},
+	Text: func(w io.Writer, i int, record interface{}) (err error) {
+		tweet := asTweet(record)
+		user := tweet.User()
+		_, err = fmt.Fprintf(w, "%v.) %v\nFrom %v (@%v) at %v\n\n", i, TweetText(tweet),
+			user.Name(), user.ScreenName(), tweet.CreatedAt().Format(time.RFC1123))
+		return
+	},
+}
+
+// Writes twittergo.User records.
+var UserSchema = &Schema{
+	Columns: []Column{
+		userColumn("id", twittergo.User.IdStr),
+		userColumn("screen_name", twittergo.User.ScreenName),
+		userColumn("name", twittergo.User.Name),
+		userColumn("followers_count", userField("followers_count")),
+		userColumn("friends_count", userField("friends_count")),
+		userColumn("statuses_count", userField("statuses_count")),
+		userColumn("description", userField("description")),
+	},
+	Text: func(w io.Writer, i int, record interface{}) (err error) {
+		user := asUser(record)
+		_, err = fmt.Fprintf(w, "ID:                   %v\nName:                 %v\n", user.Id(), user.Name())
+		return
+	},
+}
@@ -0,0 +1,187 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Finds Twitter places by name or coordinates with geo/search.json and
+// geo/reverse_geocode.json, so commands can take "San Francisco" where the
+// API wants a place_id.
+package places
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
+)
+
+// Returned by Resolve, wrapped, when nothing matches the name.
+var ErrNotFound = errors.New("No place found")
+
+// A place as returned by the geo endpoints.
+type Place map[string]interface{}
+
+func (p Place) Id() string {
+	return output.Value(p, "id")
+}
+
+func (p Place) FullName() string {
+	return output.Value(p, "full_name")
+}
+
+// One of poi, neighborhood, city, admin or country.
+func (p Place) PlaceType() string {
+	return output.Value(p, "place_type")
+}
+
+func (p Place) CountryCode() string {
+	return output.Value(p, "country_code")
+}
+
+// Returns the center of the place, if the API included it.
+func (p Place) Centroid() (lat float64, long float64, ok bool) {
+	// GeoJSON order, longitude first.
+	pair := output.Array(p, "centroid")
+	if len(pair) != 2 {
+		return
+	}
+	long, ok1 := pair[0].(float64)
+	lat, ok2 := pair[1].(float64)
+	return lat, long, ok1 && ok2
+}
+
+func asPlace(record interface{}) Place {
+	if p, ok := record.(Place); ok {
+		return p
+	}
+	return Place{}
+}
+
+func centroid(p Place) string {
+	if lat, long, ok := p.Centroid(); ok {
+		return fmt.Sprintf("%v,%v", lat, long)
+	}
+	return ""
+}
+
+func placeColumn(name string, value func(Place) string) output.Column {
+	return output.Column{
+		Name: name,
+		Value: func(record interface{}) string {
+			return value(asPlace(record))
+		},
+	}
+}
+
+// Writes Place records.
+var Schema = &output.Schema{
+	Columns: []output.Column{
+		placeColumn("id", Place.Id),
+		placeColumn("full_name", Place.FullName),
+		placeColumn("place_type", Place.PlaceType),
+		placeColumn("country_code", Place.CountryCode),
+		placeColumn("centroid", centroid),
+	},
+	Text: func(w io.Writer, i int, record interface{}) (err error) {
+		p := asPlace(record)
+		_, err = fmt.Fprintf(w, "%v.) %v (%v, %v)\n    ID: %v  Center: %v\n",
+			i, p.FullName(), p.PlaceType(), p.CountryCode(), p.Id(), centroid(p))
+		return
+	},
+}
+
+type response map[string]interface{}
+
+func request(client *twittergo.Client, path string, params url.Values) (places []Place, err error) {
+	var (
+		req  *http.Request
+		resp *twittergo.APIResponse
+	)
+	if req, err = http.NewRequest("GET", fmt.Sprintf("%v?%v", path, params.Encode()), nil); err != nil {
+		return
+	}
+	if resp, err = client.SendRequest(req); err != nil {
+		return
+	}
+	result := response{}
+	if err = resp.Parse(&result); err != nil {
+		return
+	}
+	for _, item := range output.Array(output.Object(result, "result"), "places") {
+		if p, ok := item.(map[string]interface{}); ok {
+			places = append(places, Place(p))
+		}
+	}
+	return
+}
+
+// Searches places with geo/search.json, passing params such as query, lat,
+// long, granularity and max_results as is.
+func Search(client *twittergo.Client, params url.Values) ([]Place, error) {
+	return request(client, "/1.1/geo/search.json", params)
+}
+
+// Lists the places containing a point, smallest first, with
+// geo/reverse_geocode.json.  Optional params such as granularity are
+// passed as is.
+func Reverse(client *twittergo.Client, lat float64, long float64, params url.Values) ([]Place, error) {
+	if params == nil {
+		params = url.Values{}
+	}
+	params.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	params.Set("long", strconv.FormatFloat(long, 'f', -1, 64))
+	return request(client, "/1.1/geo/reverse_geocode.json", params)
+}
+
+// Parses "latitude,longitude".
+func ParseLatLong(s string) (lat float64, long float64, err error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		err = fmt.Errorf("%q is not latitude,longitude", s)
+		return
+	}
+	if lat, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64); err == nil {
+		long, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	}
+	if err != nil || lat < -90 || lat > 90 || long < -180 || long > 180 {
+		err = fmt.Errorf("%q is not a valid latitude,longitude", s)
+	}
+	return
+}
+
+// Finds the place meant by name, which is either a name like
+// "San Francisco" or coordinates like "37.7821,-122.4093".  Returns the
+// best match.
+func Resolve(client *twittergo.Client, name string) (place Place, err error) {
+	var found []Place
+	if lat, long, perr := ParseLatLong(name); perr == nil {
+		found, err = Reverse(client, lat, long, nil)
+	} else {
+		params := url.Values{}
+		params.Set("query", name)
+		found, err = Search(client, params)
+	}
+	if err != nil {
+		return
+	}
+	if len(found) == 0 {
+		err = fmt.Errorf("%w for %q", ErrNotFound, name)
+		return
+	}
+	return found[0], nil
+}
@@ -0,0 +1,139 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/mediaupload"
+	"github.com/kurrik/twittergo-examples/publish"
+	"github.com/kurrik/twittergo-examples/render"
+)
+
+// Errors which mean the action had already been done, so running a command
+// twice is not a failure.
+var alreadyDone = map[string]int64{
+	"retweet":   publish.ALREADYRETWEETED,
+	"like":      publish.ALREADYLIKED,
+	"unretweet": publish.NOTFOUND,
+	"delete":    publish.NOTFOUND,
+}
+
+func printTweet(label string, tweet *twittergo.Tweet) {
+	fmt.Printf("%-22v%v\n", label+":", render.Permalink(*tweet))
+	fmt.Printf("ID:                   %v\n", tweet.IdStr())
+	fmt.Printf("Tweet:                %v\n", render.Text(*tweet))
+	fmt.Printf("User:                 %v\n", tweet.User().Name())
+}
+
+// Prepares, checks and uploads media, returning the IDs to attach in
+// order.
+func attach(ctx context.Context, client *twittergo.Client, media []publish.Media, opts *mediaupload.Options) (mediaIds []string, err error) {
+	var (
+		mediaId  string
+		prepared []publish.Media
+		cleanup  func()
+	)
+	prepared, cleanup, err = publish.PrepareMedia(media, opts, func(p *mediaupload.Prepared) {
+		fmt.Printf("Prepared:             %v\n", p)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Could not prepare media: %v", err)
+	}
+	defer cleanup()
+	if err = publish.CheckMedia(prepared); err != nil {
+		return nil, fmt.Errorf("Could not attach media: %v", err)
+	}
+	for i, m := range prepared {
+		if mediaId, err = publish.UploadMedia(ctx, client, m); err != nil {
+			return nil, fmt.Errorf("Could not upload %v: %v", media[i].Path, publish.Describe(err))
+		}
+		fmt.Printf("Uploaded:             %v as %v\n", media[i].Path, mediaId)
+		mediaIds = append(mediaIds, mediaId)
+	}
+	return
+}
+
+// Runs one of the commands acting on a single Tweet.
+func act(ctx context.Context, client *twittergo.Client, args *Args) (err error) {
+	var (
+		tweet  *twittergo.Tweet
+		status = &publish.Status{Text: args.Status}
+		label  string
+		media  []publish.Media
+	)
+	if args.Command != "tweet" && args.Id == "" {
+		return fmt.Errorf("Specify the Tweet to %v with -id", args.Command)
+	}
+	if media, err = args.Media.Media(); err != nil {
+		return
+	}
+	if len(media) > 0 {
+		switch args.Command {
+		case "tweet", "reply", "quote":
+			// Checked first, so that nothing is uploaded for a Tweet which
+			// can not be sent.
+			if _, err = status.Values(); err != nil {
+				return fmt.Errorf("Could not %v: %v", args.Command, err)
+			}
+			if status.MediaIds, err = attach(ctx, client, media, args.Media.Options()); err != nil {
+				return
+			}
+		default:
+			return fmt.Errorf("Only tweet, reply and quote take -media")
+		}
+	}
+	switch args.Command {
+	case "tweet":
+		label = "Posted"
+		tweet, err = publish.Post(client, status)
+	case "reply":
+		label = "Replied"
+		status.InReplyTo = args.Id
+		status.AutoPopulateReplyMetadata = args.Mention
+		tweet, err = publish.Post(client, status)
+	case "quote":
+		label = "Quoted"
+		status.AttachmentUrl = publish.QuoteUrl(args.Id)
+		tweet, err = publish.Post(client, status)
+	case "retweet":
+		label = "Retweeted"
+		tweet, err = publish.Retweet(client, args.Id)
+	case "unretweet":
+		label = "Unretweeted"
+		tweet, err = publish.Unretweet(client, args.Id)
+	case "like":
+		label = "Liked"
+		tweet, err = publish.Like(client, args.Id)
+	case "unlike":
+		label = "Unliked"
+		tweet, err = publish.Unlike(client, args.Id)
+	case "delete":
+		label = "Deleted"
+		tweet, err = publish.Destroy(client, args.Id)
+	default:
+		return fmt.Errorf("Unknown command %v", args.Command)
+	}
+	if err != nil {
+		if code, ok := alreadyDone[args.Command]; ok && publish.HasCode(err, code) {
+			fmt.Printf("Nothing to do: %v\n", publish.Describe(err))
+			return nil
+		}
+		return fmt.Errorf("Could not %v: %v", args.Command, publish.Describe(err))
+	}
+	printTweet(label, tweet)
+	return
+}
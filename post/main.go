@@ -0,0 +1,158 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Posts Tweets and threads, and replies to, quotes, retweets, likes and
+// deletes Tweets.
+package main
+
+// Each command takes the ID of the Tweet to act on in -id, and the text of
+// new Tweets in -status:
+//   $ go run ./post -status="Hello!" tweet
+//   $ go run ./post -id=560070183650213889 -status="Agreed" reply
+//   $ go run ./post -id=560070183650213889 -status="Worth a read" quote
+//   $ go run ./post -id=560070183650213889 retweet
+//   Retweeted:            https://twitter.com/kurrik/status/560070183650213890
+//
+// Tweets, replies and quotes can have up to four images, or one GIF or
+// video, each with alt text given by the -alt flag in the same position:
+//   $ go run ./post -status="Q3 numbers" -media=sales.png,costs.png \
+//       -alt="Sales rose 20%" -alt="Costs stayed flat" tweet
+//
+// The other commands are unretweet, like, unlike and delete.  Replies
+// mention the author of the Tweet unless -mention=false.  Retweeting or
+// liking a Tweet twice, or deleting one which is gone, is not an error.
+//
+// A thread is a text or Markdown file split into Tweets by lines holding
+// only ---.  Markdown images like ![alt text](chart.png) are uploaded and
+// attached to their Tweet, with paths relative to the file:
+//   Announcing v2 of our API client!
+//   ---
+//   It is twice as fast: ![Benchmark chart](chart.png)
+//   ---
+//   Upgrade with go get -u.
+//
+// Every Tweet is checked before the first is posted.  Check them without
+// posting with thread-preview:
+//   $ go run ./post thread-preview announcement.md
+//
+// Then post the first Tweet, and each following one as a reply to the one
+// before it.  Pass -reply_to to make the whole thread a reply:
+//   $ go run ./post thread announcement.md
+//   1/3 https://twitter.com/kurrik/status/560070183650213889
+//
+// The IDs of the posted Tweets are kept in announcement.md.posted.json.  If
+// a Tweet fails, running the command again resumes after the last one
+// posted, and thread-delete deletes what was posted.  With
+// -on_error=rollback that happens right away.
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/kurrik/oauth1a"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/publish"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+func LoadCredentials() (client *twittergo.Client, err error) {
+	credentials, err := ioutil.ReadFile("CREDENTIALS")
+	if err != nil {
+		return
+	}
+	lines := strings.Split(string(credentials), "\n")
+	config := &oauth1a.ClientConfig{
+		ConsumerKey:    lines[0],
+		ConsumerSecret: lines[1],
+	}
+	user := oauth1a.NewAuthorizedConfig(lines[2], lines[3])
+	client = twittergo.NewClient(config, user)
+	return
+}
+
+type Args struct {
+	Command string
+	Id      string
+	Status  string
+	Mention bool
+	ReplyTo string
+	OnError string
+	Media   publish.MediaFlags
+	Inputs  []string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Id, "id", "", "ID of the Tweet to act on")
+	flag.StringVar(&a.Status, "status", "", "Text of the Tweet to post")
+	flag.BoolVar(&a.Mention, "mention", true, "Mention the author of the Tweet replied to")
+	flag.StringVar(&a.ReplyTo, "reply_to", "", "ID of a Tweet the thread replies to")
+	flag.StringVar(&a.OnError, "on_error", "resume", "What to do when a Tweet of a thread fails: resume or rollback")
+	a.Media.Flags(flag.CommandLine)
+	flag.Parse()
+	a.Command = flag.Arg(0)
+	if flag.NArg() > 1 {
+		a.Inputs = flag.Args()[1:]
+	}
+	return a
+}
+
+func main() {
+	var (
+		err    error
+		client *twittergo.Client
+		args   *Args
+	)
+	args = parseArgs()
+	if args.OnError != "resume" && args.OnError != "rollback" {
+		fmt.Printf("-on_error must be resume or rollback.\n")
+		os.Exit(1)
+	}
+	isThread := strings.HasPrefix(args.Command, "thread")
+	if isThread && len(args.Inputs) != 1 {
+		fmt.Printf("Pass the thread file after the command.\n")
+		os.Exit(1)
+	}
+	if args.Command == "thread-preview" {
+		if err = previewThread(args.Inputs[0], args.Media.Options()); err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if client, err = LoadCredentials(); err != nil {
+		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+		os.Exit(1)
+	}
+	// Ctrl-C stops uploads in progress.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	switch args.Command {
+	case "thread":
+		err = postThread(ctx, client, args.Inputs[0], args)
+	case "thread-delete":
+		err = deleteThread(client, args.Inputs[0])
+	case "tweet", "reply", "quote", "retweet", "unretweet", "like", "unlike", "delete":
+		err = act(ctx, client, args)
+	default:
+		err = fmt.Errorf("Unknown command %v, use tweet, reply, quote, retweet, unretweet, like, unlike, delete or thread", args.Command)
+	}
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+}
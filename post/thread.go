@@ -0,0 +1,289 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/mediaupload"
+	"github.com/kurrik/twittergo-examples/publish"
+	"github.com/kurrik/twittergo-examples/render"
+	"github.com/kurrik/twittergo-examples/twittertext"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Markdown images, ![alt text](path).
+var imagePattern = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)\)`)
+
+var blankLines = regexp.MustCompile(`\n{3,}`)
+
+// One Tweet of a thread.
+type Segment struct {
+	Text   string
+	Images []publish.Media
+}
+
+// Returns a hash of the segment, to notice edits to segments already
+// posted.
+func (s *Segment) Hash() string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%v\n", s.Text)
+	for _, image := range s.Images {
+		fmt.Fprintf(h, "%v\n%v\n", image.Path, image.Alt)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Splits a thread file into segments at lines holding only ---.  Image
+// paths are relative to dir.
+func parseThread(data string, dir string) (segments []*Segment) {
+	var current []string
+	flush := func() {
+		text := strings.Join(current, "\n")
+		current = nil
+		s := &Segment{}
+		for _, m := range imagePattern.FindAllStringSubmatch(text, -1) {
+			path := m[2]
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(dir, path)
+			}
+			s.Images = append(s.Images, publish.Media{Path: path, Alt: m[1]})
+		}
+		lines := strings.Split(imagePattern.ReplaceAllString(text, ""), "\n")
+		for i, line := range lines {
+			lines[i] = strings.TrimRight(line, " \t")
+		}
+		text = blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+		s.Text = strings.TrimSpace(text)
+		if s.Text != "" || len(s.Images) > 0 {
+			segments = append(segments, s)
+		}
+	}
+	for _, line := range strings.Split(strings.Replace(data, "\r\n", "\n", -1), "\n") {
+		if strings.TrimSpace(line) == "---" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+	return
+}
+
+// Checks every segment before anything is posted, preparing the images
+// with opts first if it is not nil.
+func checkThread(segments []*Segment, opts *mediaupload.Options) (err error) {
+	if len(segments) == 0 {
+		return fmt.Errorf("Thread is empty")
+	}
+	for i, s := range segments {
+		if _, err = twittertext.Validate(s.Text); err != nil {
+			return fmt.Errorf("Tweet %v: %v", i+1, err)
+		}
+		var (
+			images  []publish.Media
+			cleanup func()
+		)
+		images, cleanup, err = publish.PrepareMedia(s.Images, opts, func(p *mediaupload.Prepared) {
+			if p.Changed() {
+				fmt.Printf("Prepared %v\n", p)
+			}
+		})
+		if err == nil {
+			err = publish.CheckMedia(images)
+		}
+		cleanup()
+		if err != nil {
+			return fmt.Errorf("Tweet %v: %v", i+1, err)
+		}
+	}
+	return
+}
+
+// What has been posted of a thread, saved next to the thread file after
+// every Tweet.
+type ThreadState struct {
+	// IDs of the posted Tweets, in order.
+	Ids []string `json:"ids"`
+	// Hashes of the segments they were posted from.
+	Hashes []string `json:"hashes"`
+}
+
+func statePath(path string) string {
+	return path + ".posted.json"
+}
+
+func loadThreadState(path string) (state *ThreadState, err error) {
+	var data []byte
+	state = &ThreadState{}
+	if data, err = ioutil.ReadFile(statePath(path)); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	if err = json.Unmarshal(data, state); err != nil {
+		err = fmt.Errorf("Could not read %v: %v", statePath(path), err)
+	}
+	return
+}
+
+func (s *ThreadState) Save(path string) (err error) {
+	var data []byte
+	if data, err = json.MarshalIndent(s, "", "  "); err != nil {
+		return
+	}
+	tmp := statePath(path) + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	return os.Rename(tmp, statePath(path))
+}
+
+func readThread(path string, opts *mediaupload.Options) (segments []*Segment, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(path); err != nil {
+		return
+	}
+	segments = parseThread(string(data), filepath.Dir(path))
+	err = checkThread(segments, opts)
+	return
+}
+
+// Prints the segments of a thread without posting them.
+func previewThread(path string, opts *mediaupload.Options) (err error) {
+	var segments []*Segment
+	if segments, err = readThread(path, opts); err != nil {
+		return
+	}
+	for i, s := range segments {
+		fmt.Printf("%v/%v (%v)\n%v\n", i+1, len(segments), twittertext.Parse(s.Text), s.Text)
+		for _, image := range s.Images {
+			fmt.Printf("[%v: %q]\n", image.Path, image.Alt)
+		}
+		fmt.Printf("--------------------------------------------------------\n")
+	}
+	return
+}
+
+// Posts the thread in the file at path, picking up after the last Tweet
+// posted by an earlier run.
+func postThread(ctx context.Context, client *twittergo.Client, path string, args *Args) (err error) {
+	var (
+		segments []*Segment
+		state    *ThreadState
+		tweet    *twittergo.Tweet
+	)
+	if segments, err = readThread(path, args.Media.Options()); err != nil {
+		return
+	}
+	if state, err = loadThreadState(path); err != nil {
+		return
+	}
+	if len(state.Ids) > len(segments) {
+		return fmt.Errorf("%v Tweets were posted but the thread only has %v, delete them with thread-delete", len(state.Ids), len(segments))
+	}
+	for i, hash := range state.Hashes {
+		if segments[i].Hash() != hash {
+			return fmt.Errorf("Tweet %v was edited after it was posted as %v, delete the thread with thread-delete first", i+1, state.Ids[i])
+		}
+	}
+	if len(state.Ids) > 0 {
+		fmt.Printf("Resuming after Tweet %v of %v.\n", len(state.Ids), len(segments))
+	}
+	for i := len(state.Ids); i < len(segments); i++ {
+		s := segments[i]
+		status := &publish.Status{Text: s.Text, InReplyTo: args.ReplyTo}
+		if i > 0 {
+			status.InReplyTo = state.Ids[i-1]
+		}
+		var (
+			images  []publish.Media
+			cleanup func()
+		)
+		images, cleanup, err = publish.PrepareMedia(s.Images, args.Media.Options(), nil)
+		if err != nil {
+			err = fmt.Errorf("Could not prepare the images of Tweet %v: %v", i+1, err)
+		}
+		for j, image := range images {
+			var mediaId string
+			if mediaId, err = publish.UploadMedia(ctx, client, image); err != nil {
+				err = fmt.Errorf("Could not upload %v: %v", s.Images[j].Path, publish.Describe(err))
+				break
+			}
+			status.MediaIds = append(status.MediaIds, mediaId)
+		}
+		cleanup()
+		if err == nil {
+			if tweet, err = publish.Post(client, status); err != nil {
+				err = fmt.Errorf("Could not post Tweet %v: %v", i+1, publish.Describe(err))
+			}
+		}
+		if err != nil {
+			return failThread(client, path, state, args, err)
+		}
+		state.Ids = append(state.Ids, tweet.IdStr())
+		state.Hashes = append(state.Hashes, s.Hash())
+		if err = state.Save(path); err != nil {
+			return
+		}
+		fmt.Printf("%v/%v %v\n", i+1, len(segments), render.Permalink(*tweet))
+	}
+	return
+}
+
+// Handles a Tweet which could not be posted, according to -on_error.
+func failThread(client *twittergo.Client, path string, state *ThreadState, args *Args, cause error) error {
+	if args.OnError != "rollback" {
+		return fmt.Errorf("%v\nRun again to resume, or delete the %v posted Tweets with thread-delete.", cause, len(state.Ids))
+	}
+	fmt.Printf("%v\nRolling back.\n", cause)
+	if err := deleteThread(client, path); err != nil {
+		return fmt.Errorf("Could not roll back: %v", err)
+	}
+	return cause
+}
+
+// Deletes the Tweets posted from the thread at path, newest first.
+func deleteThread(client *twittergo.Client, path string) (err error) {
+	var state *ThreadState
+	if state, err = loadThreadState(path); err != nil {
+		return
+	}
+	for len(state.Ids) > 0 {
+		last := len(state.Ids) - 1
+		_, err = publish.Destroy(client, state.Ids[last])
+		if err != nil && !publish.HasCode(err, publish.NOTFOUND) {
+			return fmt.Errorf("Could not delete %v: %v", state.Ids[last], publish.Describe(err))
+		}
+		fmt.Printf("Deleted %v\n", state.Ids[last])
+		state.Ids = state.Ids[:last]
+		state.Hashes = state.Hashes[:last]
+		if err = state.Save(path); err != nil {
+			return
+		}
+	}
+	if err = os.Remove(statePath(path)); os.IsNotExist(err) {
+		err = nil
+	}
+	return
+}
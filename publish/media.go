@@ -0,0 +1,217 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/mediaupload"
+)
+
+// Endpoint for alt text, on the upload host.
+const METADATAURL = "https://upload.twitter.com/1.1/media/metadata/create.json"
+
+// Most images a Tweet can have.  GIFs and videos come alone.
+const MAXIMAGES = 4
+
+// Longest alt text, in characters.
+const MAXALTTEXT = 1000
+
+// A file to attach to a Tweet, with the alt text which describes it to
+// people who can not see it.
+type Media struct {
+	Path string
+	Alt  string
+}
+
+// Checks media before anything is uploaded: at most MAXIMAGES images or a
+// single GIF or video, each within the limits of its type.
+func CheckMedia(media []Media) (err error) {
+	if len(media) > MAXIMAGES {
+		return fmt.Errorf("%v files, at most %v images are allowed", len(media), MAXIMAGES)
+	}
+	for _, m := range media {
+		var info *mediaupload.Media
+		if n := utf8.RuneCountInString(m.Alt); n > MAXALTTEXT {
+			return fmt.Errorf("Alt text of %v is %v characters, at most %v are allowed", m.Path, n, MAXALTTEXT)
+		}
+		if info, err = mediaupload.Detect(m.Path); err != nil {
+			return
+		}
+		if err = info.Check(); err != nil {
+			return
+		}
+		if info.Category != mediaupload.IMAGE && len(media) > 1 {
+			return fmt.Errorf("%v is %v, which can not be attached along with other files", m.Path, info.Category)
+		}
+	}
+	return
+}
+
+// Uploads m with Upload and sets its alt text, returning the media ID.
+func UploadMedia(ctx context.Context, client *twittergo.Client, m Media) (mediaId string, err error) {
+	if mediaId, err = Upload(ctx, client, m.Path); err != nil {
+		return
+	}
+	if m.Alt != "" {
+		err = SetAltText(ctx, client, mediaId, m.Alt)
+	}
+	return
+}
+
+// Runs the images in media through mediaupload.Prepare, unless opts is
+// nil, calling report with each result.  Returns the media to upload in
+// their place, and a function to remove the temporary files once they are
+// uploaded.
+func PrepareMedia(media []Media, opts *mediaupload.Options, report func(p *mediaupload.Prepared)) (prepared []Media, cleanup func(), err error) {
+	var done []*mediaupload.Prepared
+	cleanup = func() {
+		for _, p := range done {
+			p.Remove()
+		}
+	}
+	if opts == nil {
+		return media, cleanup, nil
+	}
+	for _, m := range media {
+		var p *mediaupload.Prepared
+		if p, err = mediaupload.Prepare(m.Path, *opts); err != nil {
+			cleanup()
+			return nil, func() {}, err
+		}
+		done = append(done, p)
+		if report != nil {
+			report(p)
+		}
+		prepared = append(prepared, Media{Path: p.Path, Alt: m.Alt})
+	}
+	return
+}
+
+// Sets the alt text of uploaded media with media/metadata/create.json.
+// Needs to happen before the media is attached to a Tweet.
+func SetAltText(ctx context.Context, client *twittergo.Client, mediaId string, alt string) (err error) {
+	var (
+		body []byte
+		req  *http.Request
+		resp *twittergo.APIResponse
+	)
+	body, err = json.Marshal(map[string]interface{}{
+		"media_id": mediaId,
+		"alt_text": map[string]string{"text": alt},
+	})
+	if err != nil {
+		return
+	}
+	if req, err = http.NewRequestWithContext(ctx, "POST", METADATAURL, bytes.NewReader(body)); err != nil {
+		return fmt.Errorf("Could not parse request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if resp, err = client.SendRequest(req); err != nil {
+		return fmt.Errorf("Could not send request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 == 2 {
+		// Success has an empty body.
+		return nil
+	}
+	return resp.Parse(&map[string]interface{}{})
+}
+
+// A flag which may be given several times, or once with commas between
+// the values.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*f = append(*f, v)
+		}
+	}
+	return nil
+}
+
+// A flag which may be given several times, keeping commas.
+type repeatedFlag []string
+
+func (f *repeatedFlag) String() string {
+	return strings.Join(*f, "|")
+}
+
+func (f *repeatedFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// Files to attach and their alt text, from the command line.
+type MediaFlags struct {
+	Paths []string
+	Alts  []string
+	// Whether to run images through mediaupload.Prepare, and how.
+	Prepare      bool
+	MaxDimension int
+	KeepMetadata bool
+}
+
+// Defines flags on fs which fill in f:
+//
+//	-media=chart.png,photo.jpg -alt="Sales by month" -alt="The team, smiling"
+//
+// The n-th -alt describes the n-th file.  Files without one have no alt
+// text.  With -prepare, images are scaled down to -max_dimension and
+// stripped of their metadata before they are uploaded, see
+// mediaupload.Prepare.
+func (f *MediaFlags) Flags(fs *flag.FlagSet) {
+	fs.Var((*listFlag)(&f.Paths), "media", "Comma separated paths of up to 4 images, or one GIF or video, to attach")
+	fs.Var((*repeatedFlag)(&f.Alts), "alt", "Alt text of the next file in -media, may be repeated")
+	fs.BoolVar(&f.Prepare, "prepare", false, "Resize, recompress and strip metadata from images before uploading")
+	fs.IntVar(&f.MaxDimension, "max_dimension", 4096, "Longest side of prepared images, in pixels")
+	fs.BoolVar(&f.KeepMetadata, "keep_metadata", false, "Keep the metadata of prepared images which need no other change")
+}
+
+// Returns how to prepare images, or nil without -prepare.
+func (f *MediaFlags) Options() *mediaupload.Options {
+	if !f.Prepare {
+		return nil
+	}
+	return &mediaupload.Options{MaxDimension: f.MaxDimension, KeepMetadata: f.KeepMetadata}
+}
+
+// Pairs the files with their alt text.
+func (f *MediaFlags) Media() (media []Media, err error) {
+	if len(f.Alts) > len(f.Paths) {
+		return nil, fmt.Errorf("%v alt texts for %v files", len(f.Alts), len(f.Paths))
+	}
+	for i, path := range f.Paths {
+		m := Media{Path: path}
+		if i < len(f.Alts) {
+			m.Alt = strings.TrimSpace(f.Alts[i])
+		}
+		media = append(media, m)
+	}
+	return
+}
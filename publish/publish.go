@@ -0,0 +1,299 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Posts and deletes Tweets, checking their length with twittertext before
+// anything is sent.
+//
+//	tweet, err := publish.Post(client, &publish.Status{
+//	    Text:      "Hello again",
+//	    InReplyTo: "560070183650213889",
+//	})
+//
+// Files are checked with CheckMedia, then uploaded one at a time with
+// UploadMedia, which also sets their alt text.  Their media IDs go into
+// Status.MediaIds in the order they should appear.
+package publish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/mediaupload"
+	"github.com/kurrik/twittergo-examples/twittertext"
+)
+
+// Endpoint for media uploads, which live on their own host.
+const UPLOADURL = "https://upload.twitter.com/1.1/media/upload.json"
+
+// Error codes returned by the API.
+const (
+	// No Tweet with that ID, or it was deleted.
+	NOTFOUND = 144
+	// The Tweet was already liked.
+	ALREADYLIKED = 139
+	// The same text was posted moments ago.
+	DUPLICATE = 187
+	// Not allowed to see or act on the Tweet, usually a protected account.
+	FORBIDDEN = 179
+	// The Tweet was already retweeted.
+	ALREADYRETWEETED = 327
+	// The Tweet replied to was deleted or is not visible.
+	REPLYDELETED = 385
+	// Errors worth trying again later.
+	RATELIMITED  = 88
+	OVERCAPACITY = 130
+	INTERNAL     = 131
+)
+
+// Explanations of the error codes, for Describe.
+var explanations = map[int64]string{
+	NOTFOUND:         "No Tweet with that ID, it may have been deleted",
+	ALREADYLIKED:     "You already liked this Tweet",
+	DUPLICATE:        "You already posted this text, Twitter rejects duplicates",
+	FORBIDDEN:        "You are not allowed to see this Tweet",
+	ALREADYRETWEETED: "You already retweeted this Tweet",
+	REPLYDELETED:     "The Tweet you are replying to was deleted or is not visible",
+}
+
+// A Tweet to post.  Blank fields are left out.
+type Status struct {
+	Text string
+	// ID of the Tweet this one replies to.
+	InReplyTo string
+	// Let Twitter add the @mentions of the replied to Tweet.
+	AutoPopulateReplyMetadata bool
+	// URL of a Tweet to quote.
+	AttachmentUrl string
+	PlaceId       string
+	// Uploaded media, in the order they should appear.
+	MediaIds []string
+}
+
+// Checks the status and returns the parameters of statuses/update.json.
+func (s *Status) Values() (params url.Values, err error) {
+	var r *twittertext.Result
+	if r, err = twittertext.Validate(s.Text); err != nil {
+		return
+	}
+	params = url.Values{}
+	params.Set("status", r.Text)
+	if s.InReplyTo != "" {
+		params.Set("in_reply_to_status_id", s.InReplyTo)
+	}
+	if s.AutoPopulateReplyMetadata {
+		params.Set("auto_populate_reply_metadata", "true")
+	}
+	if s.AttachmentUrl != "" {
+		params.Set("attachment_url", s.AttachmentUrl)
+	}
+	if s.PlaceId != "" {
+		params.Set("place_id", s.PlaceId)
+	}
+	if len(s.MediaIds) > 0 {
+		params.Set("media_ids", strings.Join(s.MediaIds, ","))
+	}
+	return
+}
+
+// Sends a form encoded POST and parses the response into result.  Errors
+// returned by the API are passed on as is, see Describe.
+func Send(client *twittergo.Client, path string, params url.Values, result interface{}) (err error) {
+	var (
+		req  *http.Request
+		resp *twittergo.APIResponse
+	)
+	if req, err = http.NewRequest("POST", path, strings.NewReader(params.Encode())); err != nil {
+		return fmt.Errorf("Could not parse request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if resp, err = client.SendRequest(req); err != nil {
+		return fmt.Errorf("Could not send request: %w", err)
+	}
+	return resp.Parse(result)
+}
+
+// Posts s with statuses/update.json.
+func Post(client *twittergo.Client, s *Status) (tweet *twittergo.Tweet, err error) {
+	var params url.Values
+	if params, err = s.Values(); err != nil {
+		return
+	}
+	tweet = &twittergo.Tweet{}
+	if err = Send(client, "/1.1/statuses/update.json", params, tweet); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Deletes the Tweet with id, returning it as it was.
+func Destroy(client *twittergo.Client, id string) (*twittergo.Tweet, error) {
+	return act(client, "/1.1/statuses/destroy/%v.json", id)
+}
+
+// Returns the URL to quote the Tweet with id in Status.AttachmentUrl.
+func QuoteUrl(id string) string {
+	// Twitter redirects to the author's own URL.
+	return fmt.Sprintf("https://twitter.com/i/web/status/%v", id)
+}
+
+// Sends a POST about the Tweet with id, with path a format taking the id.
+func act(client *twittergo.Client, path string, id string) (tweet *twittergo.Tweet, err error) {
+	tweet = &twittergo.Tweet{}
+	if err = Send(client, fmt.Sprintf(path, url.PathEscape(id)), url.Values{}, tweet); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Retweets the Tweet with id, returning the Retweet.
+func Retweet(client *twittergo.Client, id string) (*twittergo.Tweet, error) {
+	return act(client, "/1.1/statuses/retweet/%v.json", id)
+}
+
+// Undoes a Retweet of the Tweet with id, returning the original Tweet.
+func Unretweet(client *twittergo.Client, id string) (*twittergo.Tweet, error) {
+	return act(client, "/1.1/statuses/unretweet/%v.json", id)
+}
+
+func favorite(client *twittergo.Client, path string, id string) (tweet *twittergo.Tweet, err error) {
+	params := url.Values{}
+	params.Set("id", id)
+	tweet = &twittergo.Tweet{}
+	if err = Send(client, path, params, tweet); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Likes the Tweet with id.
+func Like(client *twittergo.Client, id string) (*twittergo.Tweet, error) {
+	return favorite(client, "/1.1/favorites/create.json", id)
+}
+
+// Undoes a like of the Tweet with id.
+func Unlike(client *twittergo.Client, id string) (*twittergo.Tweet, error) {
+	return favorite(client, "/1.1/favorites/destroy.json", id)
+}
+
+// Uploads the media at path and returns its media ID, after checking it
+// against the limits of its type.  Images are streamed from the file in a
+// single request, while GIFs and videos use a chunked mediaupload.Upload.
+func Upload(ctx context.Context, client *twittergo.Client, path string) (mediaId string, err error) {
+	var (
+		info      *mediaupload.Media
+		file      *os.File
+		req       *http.Request
+		resp      *twittergo.APIResponse
+		mediaResp twittergo.MediaResponse
+	)
+	if info, err = mediaupload.Detect(path); err != nil {
+		return
+	}
+	if err = info.Check(); err != nil {
+		return
+	}
+	if info.Category != mediaupload.IMAGE {
+		upload := &mediaupload.Upload{Path: path, MediaType: info.MediaType, Category: info.Category}
+		if mediaResp, err = upload.Run(ctx, client); err != nil {
+			return
+		}
+		return mediaupload.MediaId(mediaResp), nil
+	}
+	if file, err = os.Open(path); err != nil {
+		return
+	}
+	defer file.Close()
+	form := &mediaupload.Form{
+		Params:   map[string]string{"media_category": info.Category},
+		Field:    "media",
+		FileName: filepath.Base(path),
+		Media:    file,
+		Size:     info.Size,
+	}
+	if req, err = form.Request(ctx, UPLOADURL); err != nil {
+		return
+	}
+	if resp, err = client.SendRequest(req); err != nil {
+		req.Body.Close()
+		return
+	}
+	if err = resp.Parse(&mediaResp); err != nil {
+		return
+	}
+	mediaId = mediaupload.MediaId(mediaResp)
+	return
+}
+
+// Returns true if err holds an API error with code.
+func HasCode(err error, code int64) bool {
+	if errs, ok := err.(twittergo.Errors); ok {
+		for _, val := range errs.Errors() {
+			if val.Code() == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Returns true if err may go away when the request is sent again later, as
+// with rate limits, server errors and network trouble.  Anything else, like
+// a duplicate Tweet or a file which can not be read, is not transient.
+func Transient(err error) bool {
+	var (
+		rle    twittergo.RateLimitError
+		re     twittergo.ResponseError
+		errs   twittergo.Errors
+		netErr net.Error
+	)
+	switch {
+	case errors.As(err, &rle):
+		return true
+	case errors.As(err, &re):
+		return re.Code == 429 || re.Code >= 500
+	case errors.As(err, &errs):
+		return HasCode(errs, RATELIMITED) || HasCode(errs, OVERCAPACITY) || HasCode(errs, INTERNAL)
+	case errors.As(err, &netErr):
+		return true
+	}
+	return false
+}
+
+// Turns the errors returned by the API into one readable error, explaining
+// the codes it knows.
+func Describe(err error) error {
+	if rle, ok := err.(twittergo.RateLimitError); ok {
+		return fmt.Errorf("Rate limited, reset at %v", rle.Reset)
+	}
+	if errs, ok := err.(twittergo.Errors); ok {
+		msgs := []string{}
+		for _, val := range errs.Errors() {
+			if explanation, ok := explanations[val.Code()]; ok {
+				msgs = append(msgs, fmt.Sprintf("%v (code %v)", explanation, val.Code()))
+			} else {
+				msgs = append(msgs, fmt.Sprintf("Code: %v Msg: %v", val.Code(), val.Message()))
+			}
+		}
+		return fmt.Errorf("%v", strings.Join(msgs, "; "))
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package publish
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kurrik/twittergo"
+)
+
+func apiErrors(code int64) twittergo.Errors {
+	return twittergo.Errors{
+		"errors": []interface{}{
+			map[string]interface{}{"code": float64(code), "message": "test"},
+		},
+	}
+}
+
+func TestTransient(t *testing.T) {
+	netErr := &url.Error{Op: "Post", URL: "https://api.twitter.com/1.1/statuses/update.json", Err: syscall.ECONNRESET}
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"rate limit", twittergo.RateLimitError{Reset: time.Now()}, true},
+		{"server error", twittergo.NewResponseError(503, ""), true},
+		{"too many requests", twittergo.NewResponseError(429, ""), true},
+		{"bad request", twittergo.NewResponseError(400, ""), false},
+		{"over capacity", apiErrors(OVERCAPACITY), true},
+		{"duplicate", apiErrors(DUPLICATE), false},
+		{"network", netErr, true},
+		{"wrapped network", fmt.Errorf("Could not send request: %w", netErr), true},
+		{"wrapped rate limit", fmt.Errorf("Problem sending request: %w", twittergo.RateLimitError{}), true},
+		{"missing file", &os.PathError{Op: "open", Path: "missing.png", Err: os.ErrNotExist}, false},
+		{"other", errors.New("Tweet text is empty"), false},
+	}
+	for _, test := range tests {
+		if got := Transient(test.err); got != test.want {
+			t.Errorf("%v: Transient(%v) = %v, want %v", test.name, test.err, got, test.want)
+		}
+	}
+}
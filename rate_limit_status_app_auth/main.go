@@ -15,13 +15,17 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/kurrik/oauth1a"
 	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"sort"
 	"strings"
+	"time"
 )
 
 func LoadCredentials() (client *twittergo.Client, err error) {
@@ -38,6 +42,78 @@ func LoadCredentials() (client *twittergo.Client, err error) {
 	return
 }
 
+type Args struct {
+	Output   string
+	Template string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Output, "output", output.Text, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
+	flag.Parse()
+	return a
+}
+
+// The limit for a single endpoint, flattened out of the nested
+// rate_limit_status.json response for the machine readable formats.  The
+// text format prints the response as it is.
+type RateLimit struct {
+	Resource  string    `json:"resource"`
+	Limit     int64     `json:"limit"`
+	Remaining int64     `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+func rateLimitColumn(name string, value func(*RateLimit) string) output.Column {
+	return output.Column{Name: name, Value: func(record interface{}) string {
+		return value(record.(*RateLimit))
+	}}
+}
+
+var RateLimitSchema = &output.Schema{
+	Columns: []output.Column{
+		rateLimitColumn("resource", func(r *RateLimit) string { return r.Resource }),
+		rateLimitColumn("limit", func(r *RateLimit) string { return fmt.Sprintf("%v", r.Limit) }),
+		rateLimitColumn("remaining", func(r *RateLimit) string { return fmt.Sprintf("%v", r.Remaining) }),
+		rateLimitColumn("reset", func(r *RateLimit) string { return r.Reset.Format(time.RFC3339) }),
+	},
+}
+
+func toInt64(val interface{}) int64 {
+	switch v := val.(type) {
+	case int64:
+		return v
+	case float64:
+		return int64(v)
+	case int:
+		return int64(v)
+	}
+	return 0
+}
+
+// Returns the limit of every endpoint in the response, sorted by endpoint.
+func flattenRateLimits(results map[string]interface{}) (limits []*RateLimit) {
+	resources, _ := results["resources"].(map[string]interface{})
+	for _, family := range resources {
+		endpoints, _ := family.(map[string]interface{})
+		for resource, val := range endpoints {
+			if limit, ok := val.(map[string]interface{}); ok {
+				limits = append(limits, &RateLimit{
+					Resource:  resource,
+					Limit:     toInt64(limit["limit"]),
+					Remaining: toInt64(limit["remaining"]),
+					Reset:     time.Unix(toInt64(limit["reset"]), 0),
+				})
+			}
+		}
+	}
+	sort.Slice(limits, func(i, j int) bool {
+		return limits[i].Resource < limits[j].Resource
+	})
+	return
+}
+
 func main() {
 	var (
 		err     error
@@ -45,32 +121,54 @@ func main() {
 		req     *http.Request
 		resp    *twittergo.APIResponse
 		results *map[string]interface{}
+		args    *Args
+		out     *output.Writer
 	)
+	args = parseArgs()
+	if out, err = output.NewWriter(os.Stdout, args.Output, args.Template, RateLimitSchema); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
 	client, err = LoadCredentials()
 	if err != nil {
 		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
 	url := fmt.Sprintf("/1.1/application/rate_limit_status.json")
 	req, err = http.NewRequest("GET", url, nil)
 	if err != nil {
 		fmt.Printf("Could not parse request: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
 	resp, err = client.SendRequest(req)
 	if err != nil {
 		fmt.Printf("Could not send request: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
-	fmt.Printf("Response: %v\n", resp)
+	out.Notef("Response: %v\n", resp)
 	//fmt.Printf("%v\n", resp.ReadBody())
 
 	results = &map[string]interface{}{}
 	if err = resp.Parse(results); err != nil {
 		fmt.Printf("Could not parse results: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
-	PrintMap(*results)
+	if out.IsText() {
+		PrintMap(*results)
+		return
+	}
+	for _, limit := range flattenRateLimits(*results) {
+		if err = out.Write(limit); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not write rate limit: %v\n", err)
+			out.Close()
+			os.Exit(1)
+		}
+	}
+	out.Close()
 }
 
 func PrintMap(obj interface{}) {
@@ -0,0 +1,77 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package render
+
+import (
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
+)
+
+// A photo, video or GIF attached to a Tweet.
+type Media struct {
+	Id string
+	// One of photo, video or animated_gif.
+	Type string
+	// The photo itself, or the still shown before a video plays.
+	Image string
+	// The highest bitrate MP4 of a video or GIF, blank for photos.
+	Video string
+	// Alt text, only present when requested with include_ext_alt_text.
+	Alt string
+}
+
+// Returns the URL of the highest bitrate MP4 variant of a video or GIF.
+func BestVideo(media map[string]interface{}) (best string) {
+	top := -1
+	for _, item := range output.Array(output.Object(media, "video_info"), "variants") {
+		variant, ok := item.(map[string]interface{})
+		if !ok || output.Value(variant, "content_type") != "video/mp4" {
+			continue
+		}
+		// GIFs have a single variant without a bitrate.
+		bitrate, _ := toInt(variant["bitrate"])
+		if bitrate > top {
+			top = bitrate
+			best = output.Value(variant, "url")
+		}
+	}
+	return
+}
+
+// Returns the media attached to a Tweet.  Only extended_entities lists
+// every photo of a Tweet with several, so it is preferred when present.
+func AllMedia(tweet twittergo.Tweet) (media []Media) {
+	entities, extended := output.TweetEntities(tweet)
+	if extended == nil {
+		extended = entities
+	}
+	for _, item := range output.Array(extended, "media") {
+		obj, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		m := Media{
+			Id:    output.Value(obj, "id_str"),
+			Type:  output.Value(obj, "type"),
+			Image: output.Value(obj, "media_url_https"),
+			Alt:   output.Value(obj, "ext_alt_text"),
+		}
+		if m.Type != "photo" {
+			m.Video = BestVideo(obj)
+		}
+		media = append(media, m)
+	}
+	return
+}
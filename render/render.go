@@ -0,0 +1,190 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Renders Tweets as HTML or plain text, with their entities linked the way
+// twitter.com shows them.
+package render
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
+)
+
+// An entity found in the text of a Tweet.  Start and End are offsets in
+// code points, as returned by the API.
+type entity struct {
+	Start int
+	End   int
+	Href  string
+	Text  string
+	// Media links are dropped from the text since the media is shown
+	// separately.
+	Hidden bool
+}
+
+// Converts a JSON number of any decoded type to an int.
+func toInt(v interface{}) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	case string:
+		var i int
+		_, err := fmt.Sscan(n, &i)
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// Returns the [start, end) pair stored under key, like indices.
+func span(m map[string]interface{}, key string) (start int, end int, ok bool) {
+	pair := output.Array(m, key)
+	if len(pair) != 2 {
+		return
+	}
+	if start, ok = toInt(pair[0]); !ok {
+		return
+	}
+	end, ok = toInt(pair[1])
+	return
+}
+
+// Returns the object holding the full text and entities of a Tweet, which is
+// extended_tweet for streamed Tweets over 140 characters.
+func source(tweet twittergo.Tweet) map[string]interface{} {
+	if ext := output.Object(tweet, "extended_tweet"); ext != nil {
+		return ext
+	}
+	return tweet
+}
+
+func entities(tweet twittergo.Tweet) (found []entity) {
+	ents, _ := output.TweetEntities(tweet)
+	add := func(kind string, link func(obj map[string]interface{}) entity) {
+		for _, item := range output.Array(ents, kind) {
+			obj, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			start, end, ok := span(obj, "indices")
+			if !ok {
+				continue
+			}
+			e := link(obj)
+			e.Start, e.End = start, end
+			found = append(found, e)
+		}
+	}
+	add("urls", func(obj map[string]interface{}) entity {
+		e := entity{
+			Href: output.Value(obj, "expanded_url"),
+			Text: output.Value(obj, "display_url"),
+		}
+		if e.Href == "" {
+			e.Href = output.Value(obj, "url")
+		}
+		if e.Text == "" {
+			e.Text = e.Href
+		}
+		return e
+	})
+	add("user_mentions", func(obj map[string]interface{}) entity {
+		name := output.Value(obj, "screen_name")
+		return entity{Href: "https://twitter.com/" + name, Text: "@" + name}
+	})
+	add("hashtags", func(obj map[string]interface{}) entity {
+		tag := output.Value(obj, "text")
+		return entity{Href: "https://twitter.com/hashtag/" + url.PathEscape(tag), Text: "#" + tag}
+	})
+	add("symbols", func(obj map[string]interface{}) entity {
+		sym := output.Value(obj, "text")
+		return entity{Href: "https://twitter.com/search?q=" + url.QueryEscape("$"+sym), Text: "$" + sym}
+	})
+	add("media", func(obj map[string]interface{}) entity {
+		return entity{Hidden: true}
+	})
+	sort.Slice(found, func(i, j int) bool {
+		return found[i].Start < found[j].Start
+	})
+	return
+}
+
+// Walks the displayed text of a Tweet, calling plain for the text between
+// entities and link for each entity.  Leading reply mentions outside
+// display_text_range are skipped, as twitter.com does.
+func walk(tweet twittergo.Tweet, plain func(text string), link func(e entity)) {
+	text := []rune(output.TweetText(tweet))
+	start, end, ok := span(source(tweet), "display_text_range")
+	if !ok || start < 0 || end > len(text) || start > end {
+		start, end = 0, len(text)
+	}
+	pos := start
+	for _, e := range entities(tweet) {
+		if e.Start < pos || e.End > end || e.Start > e.End {
+			continue
+		}
+		plain(string(text[pos:e.Start]))
+		if !e.Hidden {
+			link(e)
+		}
+		pos = e.End
+	}
+	plain(string(text[pos:end]))
+}
+
+// Returns the text of a Tweet as HTML, with links for URLs, mentions,
+// hashtags and cashtags.  Links to attached media are removed.
+func HTML(tweet twittergo.Tweet) template.HTML {
+	var out strings.Builder
+	walk(tweet, func(text string) {
+		// The API escapes <, > and &, so undo that before escaping again.
+		text = template.HTMLEscapeString(html.UnescapeString(text))
+		out.WriteString(strings.Replace(text, "\n", "<br>\n", -1))
+	}, func(e entity) {
+		fmt.Fprintf(&out, `<a href="%v">%v</a>`,
+			template.HTMLEscapeString(e.Href), template.HTMLEscapeString(e.Text))
+	})
+	return template.HTML(strings.TrimSpace(out.String()))
+}
+
+// Returns the text of a Tweet for reading without markup, with URLs expanded
+// and links to attached media removed.
+func Text(tweet twittergo.Tweet) string {
+	var out strings.Builder
+	walk(tweet, func(text string) {
+		out.WriteString(html.UnescapeString(text))
+	}, func(e entity) {
+		if strings.HasPrefix(e.Href, "http") && !strings.HasPrefix(e.Href, "https://twitter.com/") {
+			out.WriteString(e.Href)
+		} else {
+			out.WriteString(e.Text)
+		}
+	})
+	return strings.TrimSpace(out.String())
+}
+
+// Returns the address of a Tweet on twitter.com.
+func Permalink(tweet twittergo.Tweet) string {
+	return fmt.Sprintf("https://twitter.com/%v/status/%v", tweet.User().ScreenName(), tweet.IdStr())
+}
@@ -0,0 +1,423 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Queues Tweets to be posted later, and posts them when they are due.
+package main
+
+// Add Tweets to the queue with a time in -at, either absolute or relative
+// to now.  Images in -media, a -place and a -reply_to target are optional:
+//   $ go run ./schedule -at="2026-10-20 09:00" -status="Good morning!" add
+//   $ go run ./schedule -at=+2h -status="Slides are up" -media=a.png,b.png add
+//   $ go run ./schedule -at=+3h -status="Our team" -media=team.jpg -alt="Five people on a rooftop" add
+//   Queued #2 for 2026-10-19 16:12.
+//
+// With -prepare, images are scaled down and stripped of their metadata
+// right before they are uploaded, see mediaupload.Prepare.
+//
+// Then keep the daemon running to post each Tweet when it is due:
+//   $ go run ./schedule run
+//
+// It checks the queue every -interval, so Tweets can be added while it
+// runs.  A Tweet which fails because of rate limits, server errors or
+// network trouble is tried again later, up to MAXATTEMPTS times.  One the
+// API rejects, like a duplicate, is marked failed right away.  The list
+// command shows every queued Tweet with its state, and the ID of the Tweet
+// once posted.  Pending Tweets can be removed with cancel -id=N.
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/kurrik/oauth1a"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
+	"github.com/kurrik/twittergo-examples/places"
+	"github.com/kurrik/twittergo-examples/publish"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// Attempts before a Tweet is marked failed.
+	MAXATTEMPTS = 5
+	// Wait after the first failed attempt, doubling with every attempt.
+	RETRYWAIT = time.Duration(1) * time.Minute
+	// Longest wait between attempts.
+	MAXRETRYWAIT = time.Duration(1) * time.Hour
+	// Formats accepted by -at, besides a +duration.
+	TIMEFORMAT = "2006-01-02 15:04"
+)
+
+func LoadCredentials() (client *twittergo.Client, err error) {
+	credentials, err := ioutil.ReadFile("CREDENTIALS")
+	if err != nil {
+		return
+	}
+	lines := strings.Split(string(credentials), "\n")
+	config := &oauth1a.ClientConfig{
+		ConsumerKey:    lines[0],
+		ConsumerSecret: lines[1],
+	}
+	user := oauth1a.NewAuthorizedConfig(lines[2], lines[3])
+	client = twittergo.NewClient(config, user)
+	return
+}
+
+type Args struct {
+	Command  string
+	Queue    string
+	At       string
+	Status   string
+	Media    publish.MediaFlags
+	Place    string
+	ReplyTo  string
+	Id       int
+	Interval time.Duration
+	Once     bool
+	Output   string
+	Template string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Queue, "queue", "schedule.json", "File holding the queue")
+	flag.StringVar(&a.At, "at", "", "When to post: \"YYYY-MM-DD HH:MM\" in local time, RFC 3339, or +duration")
+	flag.StringVar(&a.Status, "status", "", "Text of the Tweet")
+	a.Media.Flags(flag.CommandLine)
+	flag.StringVar(&a.Place, "place", "", "Name or latitude,longitude of the place")
+	flag.StringVar(&a.ReplyTo, "reply_to", "", "ID of the Tweet to reply to")
+	flag.IntVar(&a.Id, "id", 0, "Queued Tweet to cancel")
+	flag.DurationVar(&a.Interval, "interval", 30*time.Second, "How often run checks the queue")
+	flag.BoolVar(&a.Once, "once", false, "Post the Tweets which are due and exit, instead of running on")
+	flag.StringVar(&a.Output, "output", output.Text, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
+	flag.Parse()
+	a.Command = flag.Arg(0)
+	return a
+}
+
+func parseTime(value string, now time.Time) (t time.Time, err error) {
+	var d time.Duration
+	switch {
+	case value == "":
+		err = fmt.Errorf("Specify when to post with -at")
+	case strings.HasPrefix(value, "+"):
+		if d, err = time.ParseDuration(value[1:]); err == nil {
+			t = now.Add(d)
+		}
+	default:
+		if t, err = time.ParseInLocation(TIMEFORMAT, value, time.Local); err != nil {
+			t, err = time.Parse(time.RFC3339, value)
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("-at must look like %q, RFC 3339 or +2h: %v", TIMEFORMAT, err)
+	}
+	return
+}
+
+func asPost(record interface{}) *Post {
+	if p, ok := record.(*Post); ok {
+		return p
+	}
+	return &Post{}
+}
+
+func postColumn(name string, value func(p *Post) string) output.Column {
+	return output.Column{
+		Name: name,
+		Value: func(record interface{}) string {
+			return value(asPost(record))
+		},
+	}
+}
+
+var PostSchema = &output.Schema{
+	Columns: []output.Column{
+		postColumn("id", func(p *Post) string { return fmt.Sprintf("%v", p.Id) }),
+		postColumn("at", func(p *Post) string { return p.At.Format(time.RFC3339) }),
+		postColumn("state", func(p *Post) string { return p.State }),
+		postColumn("status", func(p *Post) string { return p.Status }),
+		postColumn("media", func(p *Post) string { return strings.Join(p.Media, ",") }),
+		postColumn("place", func(p *Post) string { return p.Place }),
+		postColumn("reply_to", func(p *Post) string { return p.ReplyTo }),
+		postColumn("attempts", func(p *Post) string { return fmt.Sprintf("%v", p.Attempts) }),
+		postColumn("last_error", func(p *Post) string { return p.LastError }),
+		postColumn("tweet_id", func(p *Post) string { return p.TweetId }),
+	},
+	Text: func(w io.Writer, i int, record interface{}) (err error) {
+		p := asPost(record)
+		state := p.State
+		switch {
+		case p.State == POSTED:
+			state += " as " + p.TweetId
+		case p.LastError != "":
+			state += fmt.Sprintf(" after %v attempts: %v", p.Attempts, p.LastError)
+		}
+		_, err = fmt.Fprintf(w, "#%v %v %v\n    %v\n", p.Id, p.At.Local().Format(TIMEFORMAT), state, p.Status)
+		return
+	},
+}
+
+// Queues a Tweet, checking as much as possible without credentials.
+func add(args *Args) (err error) {
+	var (
+		q     *Queue
+		media []publish.Media
+	)
+	p := &Post{
+		Status:  args.Status,
+		Place:   args.Place,
+		ReplyTo: args.ReplyTo,
+	}
+	if p.At, err = parseTime(args.At, time.Now()); err != nil {
+		return
+	}
+	if media, err = args.Media.Media(); err != nil {
+		return
+	}
+	for _, m := range media {
+		// The daemon may run elsewhere.
+		if m.Path, err = filepath.Abs(m.Path); err != nil {
+			return
+		}
+		p.Media = append(p.Media, m.Path)
+		p.Alt = append(p.Alt, m.Alt)
+	}
+	p.Prepare = args.Media.Options()
+	prepared, cleanup, err := publish.PrepareMedia(p.Attachments(), p.Prepare, nil)
+	if err != nil {
+		return
+	}
+	defer cleanup()
+	if err = check(p, prepared); err != nil {
+		return
+	}
+	if q, err = Lock(args.Queue); err != nil {
+		return
+	}
+	defer q.Unlock()
+	q.Add(p)
+	if err = q.Save(); err != nil {
+		return
+	}
+	fmt.Printf("Queued #%v for %v.\n", p.Id, p.At.Local().Format(TIMEFORMAT))
+	return
+}
+
+func list(args *Args) (err error) {
+	var (
+		q   *Queue
+		out *output.Writer
+	)
+	if out, err = output.NewWriter(os.Stdout, args.Output, args.Template, PostSchema); err != nil {
+		return
+	}
+	if q, err = Lock(args.Queue); err != nil {
+		return
+	}
+	q.Unlock()
+	for _, p := range q.Posts {
+		if err = out.Write(p); err != nil {
+			return
+		}
+	}
+	out.Notef("%v queued Tweets.\n", out.Count())
+	return out.Close()
+}
+
+func cancel(args *Args) (err error) {
+	var q *Queue
+	if q, err = Lock(args.Queue); err != nil {
+		return
+	}
+	defer q.Unlock()
+	p := q.Get(args.Id)
+	if p == nil {
+		return fmt.Errorf("No queued Tweet #%v", args.Id)
+	}
+	if p.State != PENDING {
+		return fmt.Errorf("Tweet #%v is already %v", p.Id, p.State)
+	}
+	p.State = CANCELED
+	if err = q.Save(); err != nil {
+		return
+	}
+	fmt.Printf("Canceled #%v.\n", p.Id)
+	return
+}
+
+// Checks what can be checked of a post before it is sent, with media as
+// returned by PrepareMedia for it.  Failing this is permanent.
+func check(p *Post, media []publish.Media) (err error) {
+	status := &publish.Status{Text: p.Status}
+	if _, err = status.Values(); err != nil {
+		return
+	}
+	return publish.CheckMedia(media)
+}
+
+// Posts p, returning whether a failure is worth trying again.
+func send(client *twittergo.Client, p *Post) (tweet *twittergo.Tweet, transient bool, err error) {
+	var (
+		place   places.Place
+		mediaId string
+	)
+	media, cleanup, err := publish.PrepareMedia(p.Attachments(), p.Prepare, nil)
+	if err != nil {
+		return
+	}
+	defer cleanup()
+	if err = check(p, media); err != nil {
+		return
+	}
+	status := &publish.Status{Text: p.Status, InReplyTo: p.ReplyTo}
+	if p.Place != "" {
+		if place, err = places.Resolve(client, p.Place); err != nil {
+			return nil, publish.Transient(err) && !errors.Is(err, places.ErrNotFound), err
+		}
+		status.PlaceId = place.Id()
+	}
+	for i, m := range media {
+		if mediaId, err = publish.UploadMedia(context.Background(), client, m); err != nil {
+			return nil, publish.Transient(err), fmt.Errorf("Could not upload %v: %v", p.Media[i], publish.Describe(err))
+		}
+		status.MediaIds = append(status.MediaIds, mediaId)
+	}
+	if tweet, err = publish.Post(client, status); err != nil {
+		return nil, publish.Transient(err), publish.Describe(err)
+	}
+	return
+}
+
+// Records the outcome of an attempt to post p.
+func record(p *Post, tweet *twittergo.Tweet, transient bool, err error, now time.Time) {
+	if err == nil {
+		p.State = POSTED
+		p.TweetId = tweet.IdStr()
+		p.PostedAt = now
+		p.LastError = ""
+		fmt.Printf("Posted #%v as %v.\n", p.Id, p.TweetId)
+		return
+	}
+	p.Attempts++
+	p.LastError = err.Error()
+	if !transient || p.Attempts >= MAXATTEMPTS {
+		p.State = FAILED
+		fmt.Printf("Gave up on #%v after %v attempts: %v\n", p.Id, p.Attempts, err)
+		return
+	}
+	wait := RETRYWAIT << uint(p.Attempts-1)
+	if wait > MAXRETRYWAIT {
+		wait = MAXRETRYWAIT
+	}
+	p.NextTry = now.Add(wait)
+	fmt.Printf("Could not post #%v, trying again in %v: %v\n", p.Id, wait, err)
+}
+
+// Posts every due Tweet.  The queue is not locked while a Tweet is being
+// posted, so adding Tweets does not have to wait for uploads.
+func postDue(client *twittergo.Client, args *Args) (err error) {
+	var (
+		q         *Queue
+		tweet     *twittergo.Tweet
+		transient bool
+		sendErr   error
+	)
+	for {
+		if q, err = Lock(args.Queue); err != nil {
+			return
+		}
+		due := q.NextDue(time.Now())
+		q.Unlock()
+		if due == nil {
+			return
+		}
+		tweet, transient, sendErr = send(client, due)
+		if err = recordResult(args.Queue, due.Id, tweet, transient, sendErr); err != nil {
+			return
+		}
+	}
+}
+
+// Records the outcome of sending post id in the queue at path.  Once a Tweet
+// went out this keeps trying, since the post would be sent again if it was
+// never marked as posted.
+func recordResult(path string, id int, tweet *twittergo.Tweet, transient bool, sendErr error) (err error) {
+	var q *Queue
+	for attempt := 1; ; attempt++ {
+		if q, err = Lock(path); err == nil {
+			if p := q.Get(id); p != nil {
+				record(p, tweet, transient, sendErr, time.Now())
+			}
+			err = q.Save()
+			q.Unlock()
+		}
+		if err == nil || sendErr != nil {
+			return
+		}
+		if attempt >= MAXATTEMPTS {
+			return fmt.Errorf("Posted #%v as %v but could not record it, cancel #%v so it is not posted again: %v",
+				id, tweet.IdStr(), id, err)
+		}
+		fmt.Fprintf(os.Stderr, "Could not record #%v as posted, trying again in %v: %v\n", id, RETRYWAIT, err)
+		time.Sleep(RETRYWAIT)
+	}
+}
+
+func run(client *twittergo.Client, args *Args) (err error) {
+	for {
+		if err = postDue(client, args); err != nil {
+			return
+		}
+		if args.Once {
+			return
+		}
+		time.Sleep(args.Interval)
+	}
+}
+
+func main() {
+	var (
+		err    error
+		client *twittergo.Client
+		args   *Args
+	)
+	args = parseArgs()
+	switch args.Command {
+	case "add":
+		err = add(args)
+	case "", "list":
+		err = list(args)
+	case "cancel":
+		err = cancel(args)
+	case "run":
+		if client, err = LoadCredentials(); err != nil {
+			fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+			os.Exit(1)
+		}
+		err = run(client, args)
+	default:
+		err = fmt.Errorf("Unknown command %v, use add, list, cancel or run", args.Command)
+	}
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kurrik/twittergo-examples/mediaupload"
+	"github.com/kurrik/twittergo-examples/publish"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+)
+
+// States of a queued post.
+const (
+	PENDING  = "pending"
+	POSTED   = "posted"
+	FAILED   = "failed"
+	CANCELED = "canceled"
+)
+
+// How long to wait for another process to release the queue.
+const LOCKWAIT = time.Duration(10) * time.Second
+
+// Locks older than this were left behind by a process which died.
+const STALELOCK = time.Duration(2) * time.Minute
+
+// A Tweet waiting to be posted, or the record of one which was.
+type Post struct {
+	Id     int       `json:"id"`
+	At     time.Time `json:"at"`
+	Status string    `json:"status"`
+	// Paths of images to attach, and their alt text in the same order.
+	Media []string `json:"media,omitempty"`
+	Alt   []string `json:"alt,omitempty"`
+	// How to prepare the images before uploading, if at all.
+	Prepare *mediaupload.Options `json:"prepare,omitempty"`
+	// Name or latitude,longitude of the place, see places.Resolve.
+	Place   string `json:"place,omitempty"`
+	ReplyTo string `json:"reply_to,omitempty"`
+	State   string `json:"state"`
+	// Failed attempts so far, and when to try again.
+	Attempts  int       `json:"attempts,omitempty"`
+	NextTry   time.Time `json:"next_try"`
+	LastError string    `json:"last_error,omitempty"`
+	TweetId   string    `json:"tweet_id,omitempty"`
+	PostedAt  time.Time `json:"posted_at"`
+}
+
+// Pairs the media of the post with its alt text.
+func (p *Post) Attachments() (media []publish.Media) {
+	for i, path := range p.Media {
+		m := publish.Media{Path: path}
+		if i < len(p.Alt) {
+			m.Alt = p.Alt[i]
+		}
+		media = append(media, m)
+	}
+	return
+}
+
+// Returns true if the post should be attempted at now.
+func (p *Post) Due(now time.Time) bool {
+	return p.State == PENDING && !now.Before(p.At) && !now.Before(p.NextTry)
+}
+
+// The queue file.  Every change happens between Lock and Unlock, so the
+// daemon and the commands adding posts can run at the same time.
+type Queue struct {
+	path   string
+	NextId int     `json:"next_id"`
+	Posts  []*Post `json:"posts"`
+}
+
+func lockPath(path string) string {
+	return path + ".lock"
+}
+
+// Opens the queue at path, waiting for other processes using it.
+func Lock(path string) (q *Queue, err error) {
+	var (
+		data     []byte
+		lock     *os.File
+		deadline = time.Now().Add(LOCKWAIT)
+	)
+	for {
+		if lock, err = os.OpenFile(lockPath(path), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644); err == nil {
+			fmt.Fprintf(lock, "%v\n", os.Getpid())
+			lock.Close()
+			break
+		}
+		if !os.IsExist(err) {
+			return
+		}
+		if info, serr := os.Stat(lockPath(path)); serr == nil && time.Since(info.ModTime()) > STALELOCK {
+			os.Remove(lockPath(path))
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("Queue %v is locked, remove %v if no other process uses it", path, lockPath(path))
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	q = &Queue{path: path, NextId: 1}
+	if data, err = ioutil.ReadFile(path); err != nil {
+		if os.IsNotExist(err) {
+			return q, nil
+		}
+		q.Unlock()
+		return nil, err
+	}
+	if err = json.Unmarshal(data, q); err != nil {
+		q.Unlock()
+		return nil, fmt.Errorf("Could not read queue %v: %v", path, err)
+	}
+	return
+}
+
+// Writes the queue back.
+func (q *Queue) Save() (err error) {
+	var data []byte
+	sort.SliceStable(q.Posts, func(i, j int) bool {
+		return q.Posts[i].At.Before(q.Posts[j].At)
+	})
+	if data, err = json.MarshalIndent(q, "", "  "); err != nil {
+		return
+	}
+	tmp := q.path + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	return os.Rename(tmp, q.path)
+}
+
+func (q *Queue) Unlock() error {
+	return os.Remove(lockPath(q.path))
+}
+
+func (q *Queue) Add(p *Post) {
+	p.Id = q.NextId
+	p.State = PENDING
+	q.NextId++
+	q.Posts = append(q.Posts, p)
+}
+
+func (q *Queue) Get(id int) *Post {
+	for _, p := range q.Posts {
+		if p.Id == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// Returns the next post which is due at now, or nil.
+func (q *Queue) NextDue(now time.Time) *Post {
+	for _, p := range q.Posts {
+		if p.Due(now) {
+			return p
+		}
+	}
+	return nil
+}
@@ -12,12 +12,43 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Searches recent Tweets.
 package main
 
+// Runs a single search and prints the results:
+//   $ go run ./search -from=kurrik -hashtag=golang
+//
+// Or watches a search, polling every -interval for Tweets newer than the
+// last one seen.  New matches go to stdout, are appended to a file, or are
+// posted as JSON to a webhook, depending on -notify:
+//   $ go run ./search -query=golang -interval=5m watch
+//   $ go run ./search -query=golang -notify=matches.ndjson watch
+//   $ go run ./search -query=golang -notify=https://hooks.example.com/abc watch
+//
+// The newest Tweet seen is kept in the -state file, so a restarted watch
+// picks up where it stopped.  When more Tweets arrive between polls than
+// one poll pages through, the next polls continue paging back from where
+// it stopped before looking for newer ones.  On the first run, Tweets which
+// already match are skipped unless -backfill is given.
+//
+// The saved searches of the authenticated user are managed with saved,
+// saved-show, saved-create and saved-delete:
+//   $ go run ./search saved
+//   $ go run ./search -phrase="happy hour" -from=kurrik saved-create
+//   $ go run ./search -id=9569704 saved-delete
+//
+// saved-run runs every saved search in turn and appends the Tweets found
+// since its last run to <id>.json in -dir, paging back like watch does:
+//   $ go run ./search -dir=saved_searches saved-run
+//   Wrote 100 new Tweets for "happy hour" to saved_searches/9569704.json
+
 import (
+	"flag"
 	"fmt"
 	"github.com/kurrik/oauth1a"
 	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
+	"github.com/kurrik/twittergo-examples/searchquery"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -41,6 +72,52 @@ func LoadCredentials() (client *twittergo.Client, err error) {
 	return
 }
 
+type Args struct {
+	Command  string
+	Query    string
+	Search   searchquery.Query
+	Output   string
+	Template string
+	Interval time.Duration
+	State    string
+	Notify   string
+	Backfill bool
+	Id       string
+	Dir      string
+}
+
+const QUERYUSAGE = "Search query, combined with the other search flags. twitterapi if none are given"
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Query, "query", "", QUERYUSAGE)
+	a.Search.Flags(flag.CommandLine)
+	flag.StringVar(&a.Output, "output", output.Text, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
+	flag.DurationVar(&a.Interval, "interval", time.Minute, "Time between polls when watching")
+	flag.StringVar(&a.State, "state", "search_watch.json", "File to keep the watch position in")
+	flag.StringVar(&a.Notify, "notify", "stdout", "Where watch sends matches: stdout, a file, or a webhook URL")
+	flag.BoolVar(&a.Backfill, "backfill", false, "Also notify matches found on the first watch poll")
+	flag.StringVar(&a.Id, "id", "", "ID of the saved search to show or delete")
+	flag.StringVar(&a.Dir, "dir", "saved_searches", "Directory saved-run writes results to")
+	flag.Parse()
+	a.Command = flag.Arg(0)
+	return a
+}
+
+// Combines -query with the other search flags and checks the result.  Uses
+// fallback when no query is given.
+func buildQuery(args *Args, fallback string) (q string, err error) {
+	args.Search.Raw = args.Query
+	if args.Search.Empty() {
+		args.Search.Raw = fallback
+	}
+	if err = args.Search.Validate(); err != nil {
+		return
+	}
+	return args.Search.String(), nil
+}
+
 func main() {
 	var (
 		err     error
@@ -48,42 +125,85 @@ func main() {
 		req     *http.Request
 		resp    *twittergo.APIResponse
 		results *twittergo.SearchResults
+		args    *Args
+		out     *output.Writer
+		q       string
 	)
+	args = parseArgs()
+	switch args.Command {
+	case "", "watch":
+	case "saved", "saved-show", "saved-create", "saved-delete", "saved-run":
+		if client, err = LoadCredentials(); err != nil {
+			fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+			os.Exit(1)
+		}
+		if err = savedCommand(client, args); err != nil {
+			fmt.Printf("Could not run %v: %v\n", args.Command, err)
+			os.Exit(1)
+		}
+		return
+	default:
+		fmt.Printf("Unknown command %v\n", args.Command)
+		os.Exit(1)
+	}
+	if q, err = buildQuery(args, "twitterapi"); err != nil {
+		fmt.Printf("Invalid query: %v\n", err)
+		os.Exit(1)
+	}
+	if out, err = output.NewWriter(os.Stdout, args.Output, args.Template, output.TweetSchema); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
 	client, err = LoadCredentials()
 	if err != nil {
 		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
+	out.Notef("Query: %v\n", q)
+	if args.Command == "watch" {
+		if err = watch(client, args, q, out); err != nil {
+			fmt.Printf("Could not watch: %v\n", err)
+			out.Close()
+			os.Exit(1)
+		}
+		return
+	}
 	query := url.Values{}
-	query.Set("q", "twitterapi")
+	query.Set("q", q)
 	url := fmt.Sprintf("/1.1/search/tweets.json?%v", query.Encode())
 	req, err = http.NewRequest("GET", url, nil)
 	if err != nil {
 		fmt.Printf("Could not parse request: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
 	resp, err = client.SendRequest(req)
 	if err != nil {
 		fmt.Printf("Could not send request: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
 	results = &twittergo.SearchResults{}
 	err = resp.Parse(results)
 	if err != nil {
 		fmt.Printf("Problem parsing response: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
-	for i, tweet := range results.Statuses() {
-		user := tweet.User()
-		fmt.Printf("%v.) %v\n", i+1, tweet.Text())
-		fmt.Printf("From %v (@%v) ", user.Name(), user.ScreenName())
-		fmt.Printf("at %v\n\n", tweet.CreatedAt().Format(time.RFC1123))
+	for _, tweet := range results.Statuses() {
+		if err = out.Write(tweet); err != nil {
+			fmt.Printf("Could not write Tweet: %v\n", err)
+			out.Close()
+			os.Exit(1)
+		}
 	}
+	out.Close()
 	if resp.HasRateLimit() {
-		fmt.Printf("Rate limit:           %v\n", resp.RateLimit())
-		fmt.Printf("Rate limit remaining: %v\n", resp.RateLimitRemaining())
-		fmt.Printf("Rate limit reset:     %v\n", resp.RateLimitReset())
+		out.Notef("Rate limit:           %v\n", resp.RateLimit())
+		out.Notef("Rate limit remaining: %v\n", resp.RateLimitRemaining())
+		out.Notef("Rate limit reset:     %v\n", resp.RateLimitReset())
 	} else {
-		fmt.Printf("Could not parse rate limit from response.\n")
+		out.Notef("Could not parse rate limit from response.\n")
 	}
 }
@@ -0,0 +1,259 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"fmt"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// A search saved by the authenticated user.
+type SavedSearch map[string]interface{}
+
+func (s SavedSearch) IdStr() string {
+	return output.Value(s, "id_str")
+}
+
+func (s SavedSearch) Name() string {
+	return output.Value(s, "name")
+}
+
+func (s SavedSearch) Query() string {
+	return output.Value(s, "query")
+}
+
+func (s SavedSearch) CreatedAt() time.Time {
+	t, _ := time.Parse(time.RubyDate, output.Value(s, "created_at"))
+	return t
+}
+
+type SavedSearches []SavedSearch
+
+func asSavedSearch(record interface{}) SavedSearch {
+	if s, ok := record.(SavedSearch); ok {
+		return s
+	}
+	return SavedSearch{}
+}
+
+func savedColumn(name string, key string) output.Column {
+	return output.Column{
+		Name: name,
+		Value: func(record interface{}) string {
+			return output.Value(asSavedSearch(record), key)
+		},
+	}
+}
+
+var SavedSearchSchema = &output.Schema{
+	Columns: []output.Column{
+		savedColumn("id", "id_str"),
+		savedColumn("name", "name"),
+		savedColumn("query", "query"),
+		savedColumn("created_at", "created_at"),
+	},
+	Text: func(w io.Writer, i int, record interface{}) (err error) {
+		s := asSavedSearch(record)
+		_, err = fmt.Fprintf(w, "ID:    %v\nName:  %v\nQuery: %v\n\n", s.IdStr(), s.Name(), s.Query())
+		return
+	},
+}
+
+func describeError(err error) error {
+	if errs, ok := err.(twittergo.Errors); ok {
+		msgs := []string{}
+		for _, val := range errs.Errors() {
+			msgs = append(msgs, fmt.Sprintf("Code: %v Msg: %v", val.Code(), val.Message()))
+		}
+		return fmt.Errorf("%v", strings.Join(msgs, "; "))
+	}
+	return err
+}
+
+// Sleeps until a rate limit resets.  Returns any other error unchanged.
+func handleRateLimit(err error) error {
+	if rle, ok := err.(twittergo.RateLimitError); ok {
+		dur := rle.Reset.Sub(time.Now()) + time.Second
+		if dur < MINWAIT {
+			// Don't wait less than minwait.
+			dur = MINWAIT
+		}
+		fmt.Fprintf(os.Stderr, "Rate limited. Reset at %v. Waiting for %v\n", rle.Reset, dur)
+		time.Sleep(dur)
+		return nil
+	}
+	return err
+}
+
+func sendSavedRequest(client *twittergo.Client, method string, path string, params url.Values, result interface{}) (err error) {
+	var (
+		req  *http.Request
+		resp *twittergo.APIResponse
+	)
+	for {
+		if method == "GET" {
+			req, err = http.NewRequest(method, fmt.Sprintf("%v?%v", path, params.Encode()), nil)
+		} else {
+			req, err = http.NewRequest(method, path, strings.NewReader(params.Encode()))
+		}
+		if err != nil {
+			return fmt.Errorf("Could not parse request: %v", err)
+		}
+		if method != "GET" {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if resp, err = client.SendRequest(req); err != nil {
+			return fmt.Errorf("Could not send request: %v", err)
+		}
+		if err = resp.Parse(result); err != nil {
+			if err = handleRateLimit(err); err != nil {
+				return fmt.Errorf("Problem parsing response: %v", describeError(err))
+			}
+			continue
+		}
+		return
+	}
+}
+
+// Returns the path to show or destroy the saved search with id.
+func savedPath(action string, id string) (path string, err error) {
+	if id == "" {
+		err = fmt.Errorf("Specify the saved search with -id")
+		return
+	}
+	path = fmt.Sprintf("/1.1/saved_searches/%v/%v.json", action, url.PathEscape(id))
+	return
+}
+
+// Runs one of the saved search commands.
+func savedCommand(client *twittergo.Client, args *Args) (err error) {
+	var (
+		w     *output.Writer
+		path  string
+		q     string
+		saved = SavedSearch{}
+		list  = &SavedSearches{}
+	)
+	if w, err = output.NewWriter(os.Stdout, args.Output, args.Template, SavedSearchSchema); err != nil {
+		return
+	}
+	defer w.Close()
+	switch args.Command {
+	case "saved":
+		if err = sendSavedRequest(client, "GET", "/1.1/saved_searches/list.json", url.Values{}, list); err != nil {
+			return
+		}
+		for _, s := range *list {
+			if err = w.Write(s); err != nil {
+				return
+			}
+		}
+		w.Notef("%v saved searches.\n", w.Count())
+	case "saved-show":
+		if path, err = savedPath("show", args.Id); err != nil {
+			return
+		}
+		if err = sendSavedRequest(client, "GET", path, url.Values{}, &saved); err != nil {
+			return
+		}
+		err = w.Write(saved)
+	case "saved-create":
+		if q, err = buildQuery(args, ""); err != nil {
+			return
+		}
+		params := url.Values{}
+		params.Set("query", q)
+		if err = sendSavedRequest(client, "POST", "/1.1/saved_searches/create.json", params, &saved); err != nil {
+			return
+		}
+		w.Notef("Saved search:\n")
+		err = w.Write(saved)
+	case "saved-delete":
+		if path, err = savedPath("destroy", args.Id); err != nil {
+			return
+		}
+		if err = sendSavedRequest(client, "POST", path, url.Values{}, &saved); err != nil {
+			return
+		}
+		w.Notef("Deleted saved search:\n")
+		err = w.Write(saved)
+	case "saved-run":
+		if err = sendSavedRequest(client, "GET", "/1.1/saved_searches/list.json", url.Values{}, list); err != nil {
+			return
+		}
+		for _, s := range *list {
+			if err = runSaved(client, args, s); err != nil {
+				return
+			}
+		}
+		w.Notef("Ran %v saved searches.\n", len(*list))
+	}
+	return
+}
+
+// Appends the Tweets matching s since its last run to a file named after
+// its ID in -dir.
+func runSaved(client *twittergo.Client, args *Args, s SavedSearch) (err error) {
+	var (
+		state  *WatchState
+		tweets []twittergo.Tweet
+		next   uint64
+		file   *os.File
+		w      *output.Writer
+	)
+	if err = os.MkdirAll(args.Dir, 0755); err != nil {
+		return
+	}
+	base := filepath.Join(args.Dir, s.IdStr())
+	if state, err = loadState(base+".state.json", s.Query()); err != nil {
+		return
+	}
+	if tweets, next, err = fetchSince(client, s.Query(), state.SinceId, state.MaxId); err != nil {
+		return fmt.Errorf("Could not run %q: %v", s.Name(), describeError(err))
+	}
+	if file, err = os.OpenFile(base+".json", os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644); err != nil {
+		return
+	}
+	defer file.Close()
+	if w, err = output.NewWriter(file, output.NDJSON, "", output.TweetSchema); err != nil {
+		return
+	}
+	for _, tweet := range tweets {
+		if state.Saw(tweet.Id()) {
+			continue
+		}
+		if err = w.Write(tweet); err != nil {
+			return
+		}
+		state.Add(tweet.Id())
+	}
+	state.Advance(tweets, next)
+	if next != 0 {
+		fmt.Fprintf(os.Stderr, "More than %v new Tweets for %q, fetching older ones on the next run; run it more often.\n", WATCHPAGES*WATCHCOUNT, s.Name())
+	}
+	if err = state.Save(base + ".state.json"); err != nil {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %v new Tweets for %q to %v.json\n", w.Count(), s.Name(), base)
+	return
+}
@@ -0,0 +1,330 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
+	"github.com/kurrik/twittergo-examples/render"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	MINWAIT = time.Duration(10) * time.Second
+	// Results per request, the most search/tweets.json returns.
+	WATCHCOUNT = 100
+	// Requests per poll when many Tweets arrive between polls.
+	WATCHPAGES = 10
+	// IDs remembered to skip Tweets which were already notified.
+	WATCHSEEN = 1000
+)
+
+// What watch remembers between runs.
+type WatchState struct {
+	Query string `json:"query"`
+	// Every match up to this Tweet has been fetched.
+	SinceId uint64 `json:"since_id,string"`
+	// Set when a poll stopped paging before it got back to SinceId.  The
+	// next poll continues below this Tweet rather than from the newest.
+	MaxId uint64 `json:"max_id,string,omitempty"`
+	// Newest Tweet fetched while paging back, which becomes SinceId once
+	// the polls reach the old SinceId.
+	NewestId uint64 `json:"newest_id,string,omitempty"`
+	// Most recently notified Tweets, oldest first.
+	Seen []uint64 `json:"seen"`
+}
+
+func loadState(path string, q string) (state *WatchState, err error) {
+	var data []byte
+	state = &WatchState{Query: q}
+	if data, err = ioutil.ReadFile(path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	saved := &WatchState{}
+	if err = json.Unmarshal(data, saved); err != nil {
+		return nil, fmt.Errorf("Could not read %v: %v", path, err)
+	}
+	if saved.Query != q {
+		fmt.Fprintf(os.Stderr, "Query changed from %q, starting over.\n", saved.Query)
+		return
+	}
+	return saved, nil
+}
+
+func (s *WatchState) Save(path string) (err error) {
+	var data []byte
+	if data, err = json.MarshalIndent(s, "", "  "); err != nil {
+		return
+	}
+	if err = ioutil.WriteFile(path+".tmp", data, 0644); err != nil {
+		return
+	}
+	return os.Rename(path+".tmp", path)
+}
+
+func (s *WatchState) Saw(id uint64) bool {
+	for _, seen := range s.Seen {
+		if seen == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *WatchState) Add(id uint64) {
+	s.Seen = append(s.Seen, id)
+	if len(s.Seen) > WATCHSEEN {
+		s.Seen = s.Seen[len(s.Seen)-WATCHSEEN:]
+	}
+}
+
+// Moves the state past tweets once every one of them was handled.  next is
+// the max_id where fetchSince stopped paging, or 0 if it got back to
+// SinceId.
+func (s *WatchState) Advance(tweets []twittergo.Tweet, next uint64) {
+	for _, tweet := range tweets {
+		if id := tweet.Id(); id > s.NewestId {
+			s.NewestId = id
+		}
+	}
+	s.MaxId = next
+	if next == 0 {
+		if s.NewestId > s.SinceId {
+			s.SinceId = s.NewestId
+		}
+		s.NewestId = 0
+	}
+}
+
+// Receives each new match.
+type Notifier interface {
+	Notify(tweet twittergo.Tweet) error
+	Close() error
+}
+
+// Writes matches to stdout or a file in any output format.
+type WriterNotifier struct {
+	w    *output.Writer
+	file *os.File
+}
+
+func (n *WriterNotifier) Notify(tweet twittergo.Tweet) error {
+	return n.w.Write(tweet)
+}
+
+func (n *WriterNotifier) Close() (err error) {
+	err = n.w.Close()
+	if n.file != nil {
+		if cerr := n.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return
+}
+
+// Posts each match to a URL as JSON.  The text field makes the body usable
+// with Slack style incoming webhooks as is.
+type WebhookNotifier struct {
+	URL   string
+	Query string
+}
+
+type WebhookMessage struct {
+	Text  string          `json:"text"`
+	Query string          `json:"query"`
+	Tweet twittergo.Tweet `json:"tweet"`
+}
+
+func (n *WebhookNotifier) Notify(tweet twittergo.Tweet) (err error) {
+	var (
+		data []byte
+		resp *http.Response
+	)
+	msg := &WebhookMessage{
+		Text: fmt.Sprintf("@%v: %v %v", tweet.User().ScreenName(),
+			render.Text(tweet), render.Permalink(tweet)),
+		Query: n.Query,
+		Tweet: tweet,
+	}
+	if data, err = json.Marshal(msg); err != nil {
+		return
+	}
+	if resp, err = http.Post(n.URL, "application/json", bytes.NewReader(data)); err != nil {
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err = fmt.Errorf("Webhook returned %v", resp.Status)
+	}
+	return
+}
+
+func (n *WebhookNotifier) Close() error {
+	return nil
+}
+
+// Picks the notifier for -notify: stdout, an http(s) URL for a webhook, or
+// the path of a file to append to.
+func newNotifier(args *Args, q string, stdout *output.Writer) (n Notifier, err error) {
+	var (
+		file *os.File
+		w    *output.Writer
+	)
+	switch {
+	case args.Notify == "stdout":
+		return &WriterNotifier{w: stdout}, nil
+	case strings.HasPrefix(args.Notify, "http://") || strings.HasPrefix(args.Notify, "https://"):
+		return &WebhookNotifier{URL: args.Notify, Query: q}, nil
+	}
+	if file, err = os.OpenFile(args.Notify, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644); err != nil {
+		return
+	}
+	format := args.Output
+	if format == output.Text || format == output.JSON {
+		// A file appended to over many runs needs one record per line.
+		format = output.NDJSON
+	}
+	if w, err = output.NewWriter(file, format, args.Template, output.TweetSchema); err != nil {
+		file.Close()
+		return
+	}
+	return &WriterNotifier{w: w, file: file}, nil
+}
+
+// Fetches the Tweets matching q which are newer than sinceId, oldest first,
+// starting below maxId if it is not 0.  Pages back with max_id when more
+// arrived than one request returns.  If WATCHPAGES pages did not reach back
+// to sinceId, next is the max_id to continue from.
+func fetchSince(client *twittergo.Client, q string, sinceId uint64, maxId uint64) (tweets []twittergo.Tweet, next uint64, err error) {
+	var (
+		req     *http.Request
+		resp    *twittergo.APIResponse
+		results *twittergo.SearchResults
+	)
+	query := url.Values{}
+	query.Set("q", q)
+	query.Set("count", fmt.Sprintf("%v", WATCHCOUNT))
+	query.Set("result_type", "recent")
+	query.Set("tweet_mode", "extended")
+	if sinceId != 0 {
+		query.Set("since_id", fmt.Sprintf("%v", sinceId))
+	}
+	for page := 0; page < WATCHPAGES; {
+		if maxId != 0 {
+			query.Set("max_id", fmt.Sprintf("%v", maxId))
+		}
+		endpoint := fmt.Sprintf("/1.1/search/tweets.json?%v", query.Encode())
+		if req, err = http.NewRequest("GET", endpoint, nil); err != nil {
+			return
+		}
+		if resp, err = client.SendRequest(req); err != nil {
+			return
+		}
+		results = &twittergo.SearchResults{}
+		if err = resp.Parse(results); err != nil {
+			if err = handleRateLimit(err); err != nil {
+				return
+			}
+			continue // Retry request.
+		}
+		page++
+		statuses := results.Statuses()
+		tweets = append(tweets, statuses...)
+		// The first poll only needs the newest page.
+		if sinceId == 0 || len(statuses) < WATCHCOUNT {
+			break
+		}
+		maxId = statuses[len(statuses)-1].Id() - 1
+		if page == WATCHPAGES {
+			next = maxId
+		}
+	}
+	// Results arrive newest first.
+	for i, j := 0, len(tweets)-1; i < j; i, j = i+1, j-1 {
+		tweets[i], tweets[j] = tweets[j], tweets[i]
+	}
+	return
+}
+
+// Polls for new matches of q every -interval until interrupted.
+func watch(client *twittergo.Client, args *Args, q string, stdout *output.Writer) (err error) {
+	var (
+		state    *WatchState
+		notifier Notifier
+		tweets   []twittergo.Tweet
+		next     uint64
+	)
+	if state, err = loadState(args.State, q); err != nil {
+		return
+	}
+	if notifier, err = newNotifier(args, q, stdout); err != nil {
+		return
+	}
+	defer notifier.Close()
+	first := state.SinceId == 0
+	for ; ; time.Sleep(args.Interval) {
+		if tweets, next, err = fetchSince(client, q, state.SinceId, state.MaxId); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not search, trying again in %v: %v\n", args.Interval, err)
+			continue
+		}
+		count := 0
+		handled := true
+		for _, tweet := range tweets {
+			if state.Saw(tweet.Id()) {
+				continue
+			}
+			if first && !args.Backfill {
+				state.Add(tweet.Id())
+				continue
+			}
+			if err = notifier.Notify(tweet); err != nil {
+				// Fetch the same Tweets at the next poll, skipping the ones
+				// already notified.
+				fmt.Fprintf(os.Stderr, "Could not notify Tweet %v: %v\n", tweet.IdStr(), err)
+				handled = false
+				break
+			}
+			state.Add(tweet.Id())
+			count++
+		}
+		if first && !args.Backfill && len(tweets) > 0 {
+			fmt.Fprintf(os.Stderr, "Skipped %v existing Tweets, watching for new ones.\n", len(tweets))
+		}
+		if handled {
+			state.Advance(tweets, next)
+			if next != 0 {
+				fmt.Fprintf(os.Stderr, "More than %v new Tweets since the last poll, fetching older ones at the next poll; try a shorter -interval.\n", WATCHPAGES*WATCHCOUNT)
+			}
+		}
+		first = false
+		if err = state.Save(args.State); err != nil {
+			return fmt.Errorf("Could not save state to %v: %v", args.State, err)
+		}
+		if count > 0 {
+			fmt.Fprintf(os.Stderr, "%v: %v new Tweets.\n", time.Now().Format(time.Kitchen), count)
+		}
+	}
+}
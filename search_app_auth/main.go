@@ -15,15 +15,16 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/kurrik/oauth1a"
 	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
 	"io/ioutil"
 	"net/http"
 	"net/url"
 	"os"
 	"strings"
-	"time"
 )
 
 func LoadCredentials() (client *twittergo.Client, err error) {
@@ -40,6 +41,19 @@ func LoadCredentials() (client *twittergo.Client, err error) {
 	return
 }
 
+type Args struct {
+	Output   string
+	Template string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Output, "output", output.Text, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
+	flag.Parse()
+	return a
+}
+
 func main() {
 	var (
 		err     error
@@ -47,10 +61,18 @@ func main() {
 		req     *http.Request
 		resp    *twittergo.APIResponse
 		results *twittergo.SearchResults
+		args    *Args
+		out     *output.Writer
 	)
+	args = parseArgs()
+	if out, err = output.NewWriter(os.Stdout, args.Output, args.Template, output.TweetSchema); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
 	client, err = LoadCredentials()
 	if err != nil {
 		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
 	query := url.Values{}
@@ -59,30 +81,35 @@ func main() {
 	req, err = http.NewRequest("GET", url, nil)
 	if err != nil {
 		fmt.Printf("Could not parse request: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
 	resp, err = client.SendRequest(req)
 	if err != nil {
 		fmt.Printf("Could not send request: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
 	results = &twittergo.SearchResults{}
 	err = resp.Parse(results)
 	if err != nil {
 		fmt.Printf("Problem parsing response: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
-	for i, tweet := range results.Statuses() {
-		user := tweet.User()
-		fmt.Printf("%v.) %v\n", i+1, tweet.Text())
-		fmt.Printf("From %v (@%v) ", user.Name(), user.ScreenName())
-		fmt.Printf("at %v\n\n", tweet.CreatedAt().Format(time.RFC1123))
+	for _, tweet := range results.Statuses() {
+		if err = out.Write(tweet); err != nil {
+			fmt.Printf("Could not write Tweet: %v\n", err)
+			out.Close()
+			os.Exit(1)
+		}
 	}
+	out.Close()
 	if resp.HasRateLimit() {
-		fmt.Printf("Rate limit:           %v\n", resp.RateLimit())
-		fmt.Printf("Rate limit remaining: %v\n", resp.RateLimitRemaining())
-		fmt.Printf("Rate limit reset:     %v\n", resp.RateLimitReset())
+		out.Notef("Rate limit:           %v\n", resp.RateLimit())
+		out.Notef("Rate limit remaining: %v\n", resp.RateLimitRemaining())
+		out.Notef("Rate limit reset:     %v\n", resp.RateLimitReset())
 	} else {
-		fmt.Printf("Could not parse rate limit from response.\n")
+		out.Notef("Could not parse rate limit from response.\n")
 	}
 }
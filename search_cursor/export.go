@@ -0,0 +1,147 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+// The time span of the Tweets written so far.
+type Coverage struct {
+	Count  int       `json:"count"`
+	Oldest time.Time `json:"oldest"`
+	Newest time.Time `json:"newest"`
+}
+
+func (c *Coverage) Add(tweet twittergo.Tweet) {
+	t := tweet.CreatedAt()
+	if c.Count == 0 || t.Before(c.Oldest) {
+		c.Oldest = t
+	}
+	if c.Count == 0 || t.After(c.Newest) {
+		c.Newest = t
+	}
+	c.Count++
+}
+
+func (c *Coverage) String() string {
+	if c.Count == 0 {
+		return "no Tweets"
+	}
+	return fmt.Sprintf("%v Tweets from %v to %v", c.Count,
+		c.Oldest.UTC().Format(time.RFC3339), c.Newest.UTC().Format(time.RFC3339))
+}
+
+// Where an export stopped, saved next to the output after every page.
+type Checkpoint struct {
+	Query string `json:"query"`
+	// The max_id of the next request, 0 before the first.
+	MaxId uint64 `json:"max_id,string"`
+	// Bytes of the output written when the checkpoint was saved.  Anything
+	// after is dropped on resume, since it was not checkpointed.
+	Size     int64    `json:"size"`
+	Done     bool     `json:"done"`
+	Coverage Coverage `json:"coverage"`
+}
+
+// Writes search results to an NDJSON file, checkpointing as it goes.
+type Exporter struct {
+	path       string
+	file       *os.File
+	w          *output.Writer
+	Checkpoint *Checkpoint
+}
+
+func checkpointPath(path string) string {
+	return path + ".checkpoint"
+}
+
+// Opens the export of q to path.  An unfinished export of the same query is
+// resumed, anything else at path is replaced.
+func openExport(path string, q string) (e *Exporter, err error) {
+	var data []byte
+	e = &Exporter{path: path, Checkpoint: &Checkpoint{Query: q}}
+	if data, err = ioutil.ReadFile(checkpointPath(path)); err == nil {
+		cp := &Checkpoint{}
+		if err = json.Unmarshal(data, cp); err != nil {
+			return nil, fmt.Errorf("Could not read checkpoint: %v", err)
+		}
+		if cp.Query == q && !cp.Done {
+			e.Checkpoint = cp
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+	if e.file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE, 0644); err != nil {
+		return nil, err
+	}
+	if err = e.file.Truncate(e.Checkpoint.Size); err == nil {
+		_, err = e.file.Seek(e.Checkpoint.Size, 0)
+	}
+	if err != nil {
+		e.file.Close()
+		return nil, err
+	}
+	if e.w, err = output.NewWriter(e.file, output.NDJSON, "", output.TweetSchema); err != nil {
+		e.file.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+// Returns true when continuing an earlier export.
+func (e *Exporter) Resumed() bool {
+	return e.Checkpoint.MaxId != 0
+}
+
+func (e *Exporter) Write(tweet twittergo.Tweet) error {
+	e.Checkpoint.Coverage.Add(tweet)
+	return e.w.Write(tweet)
+}
+
+// Records that every Tweet up to maxId was written.
+func (e *Exporter) Save(maxId uint64, done bool) (err error) {
+	var (
+		data []byte
+		pos  int64
+	)
+	if err = e.file.Sync(); err != nil {
+		return
+	}
+	if pos, err = e.file.Seek(0, 1); err != nil {
+		return
+	}
+	e.Checkpoint.MaxId = maxId
+	e.Checkpoint.Size = pos
+	e.Checkpoint.Done = done
+	if data, err = json.MarshalIndent(e.Checkpoint, "", "  "); err != nil {
+		return
+	}
+	tmp := checkpointPath(e.path) + ".tmp"
+	if err = ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	return os.Rename(tmp, checkpointPath(e.path))
+}
+
+func (e *Exporter) Close() error {
+	return e.file.Close()
+}
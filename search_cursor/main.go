@@ -12,13 +12,35 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Exports every result of a search.
 package main
 
+// Pages back through the results of a search with max_id until there are no
+// more, or until Tweets older than -since are reached.  Each request asks
+// for 100 Tweets in extended mode, with entities:
+//   $ go run ./search_cursor -hashtag=golang -since=2015-03-01
+//
+// With -out, results are written to a file as NDJSON and a checkpoint is
+// kept beside it in <out>.checkpoint after every page.  Rerunning the same
+// query resumes an interrupted export, for instance one killed while
+// waiting out a rate limit:
+//   $ go run ./search_cursor -query="golang" -since=2015-03-01 -out=golang.json
+//   Got 100 Tweets, 179 calls available.
+//   ...
+//   Rate limited. Reset at 2015-03-08 17:13:55 -0700 PDT. Waiting for 13m49s
+//   ...
+//   --------------------------------------------------------
+//   Wrote 17208 Tweets from 2015-03-01T00:00:07Z to 2015-03-08T23:59:58Z to golang.json
+//
+// The standard search API only covers about the last 7 days.
+
 import (
 	"flag"
 	"fmt"
 	"github.com/kurrik/oauth1a"
 	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
+	"github.com/kurrik/twittergo-examples/searchquery"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -27,7 +49,11 @@ import (
 	"time"
 )
 
-const MINWAIT = time.Duration(10) * time.Second
+const (
+	MINWAIT = time.Duration(10) * time.Second
+	// Results per request, the most search/tweets.json returns.
+	COUNT = 100
+)
 
 func LoadCredentials() (client *twittergo.Client, err error) {
 	credentials, err := ioutil.ReadFile("CREDENTIALS")
@@ -46,48 +72,117 @@ func LoadCredentials() (client *twittergo.Client, err error) {
 
 type Args struct {
 	Query      string
+	Search     searchquery.Query
 	ResultType string
+	OutputFile string
+	Output     string
+	Template   string
 }
 
+const QUERYUSAGE = "Search query, combined with the other search flags. twitterapi if none are given"
+
 func parseArgs() *Args {
 	a := &Args{}
-	flag.StringVar(&a.Query, "query", "twitterapi", "Search query")
-	flag.StringVar(&a.ResultType, "result_type", "", "Type of search results to receive")
+	flag.StringVar(&a.Query, "query", "", QUERYUSAGE)
+	a.Search.Flags(flag.CommandLine)
+	flag.StringVar(&a.ResultType, "result_type", "", "Type of search results to receive: mixed, recent or popular")
+	flag.StringVar(&a.OutputFile, "out", "", "Export to this NDJSON file, resuming unfinished exports")
+	flag.StringVar(&a.Output, "output", output.Text, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
 	flag.Parse()
 	return a
 }
 
+// Combines -query with the other search flags and checks the result.
+func buildQuery(args *Args) (q string, err error) {
+	args.Search.Raw = args.Query
+	if args.Search.Empty() {
+		args.Search.Raw = "twitterapi"
+	}
+	if err = args.Search.Validate(); err != nil {
+		return
+	}
+	return args.Search.String(), nil
+}
+
+// Returns the -since and -until dates, zero if not given.
+func bounds(args *Args) (since time.Time, until time.Time) {
+	// Already checked by Validate.
+	if args.Search.Since != "" {
+		since, _ = time.Parse(searchquery.DATEFORMAT, args.Search.Since)
+	}
+	if args.Search.Until != "" {
+		until, _ = time.Parse(searchquery.DATEFORMAT, args.Search.Until)
+	}
+	return
+}
+
 func main() {
 	var (
-		err     error
-		client  *twittergo.Client
-		req     *http.Request
-		resp    *twittergo.APIResponse
-		results *twittergo.SearchResults
-		args    *Args
-		i       int
+		err      error
+		client   *twittergo.Client
+		req      *http.Request
+		resp     *twittergo.APIResponse
+		results  *twittergo.SearchResults
+		args     *Args
+		out      *output.Writer
+		export   *Exporter
+		q        string
+		maxId    uint64
+		coverage *Coverage
 	)
 	args = parseArgs()
+	if q, err = buildQuery(args); err != nil {
+		fmt.Printf("Invalid query: %v\n", err)
+		os.Exit(1)
+	}
+	if out, err = output.NewWriter(os.Stdout, args.Output, args.Template, output.TweetSchema); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	defer out.Close()
 	if client, err = LoadCredentials(); err != nil {
 		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
+	coverage = &Coverage{}
+	if args.OutputFile != "" {
+		if export, err = openExport(args.OutputFile, q); err != nil {
+			fmt.Printf("Could not open %v: %v\n", args.OutputFile, err)
+			out.Close()
+			os.Exit(1)
+		}
+		defer export.Close()
+		coverage = &export.Checkpoint.Coverage
+		maxId = export.Checkpoint.MaxId
+		if export.Resumed() {
+			out.Notef("Resuming export of %v at max_id %v.\n", coverage, maxId)
+		}
+	}
+	since, until := bounds(args)
+	out.Notef("Query: %v\n", q)
 	query := url.Values{}
-	query.Set("q", args.Query)
+	query.Set("q", q)
+	query.Set("count", fmt.Sprintf("%v", COUNT))
+	query.Set("include_entities", "true")
+	query.Set("tweet_mode", "extended")
 	if args.ResultType != "" {
 		query.Set("result_type", args.ResultType)
 	}
-	i = 1
 	for {
+		if maxId != 0 {
+			query.Set("max_id", fmt.Sprintf("%v", maxId))
+		}
 		url := fmt.Sprintf("/1.1/search/tweets.json?%v", query.Encode())
 		req, err = http.NewRequest("GET", url, nil)
 		if err != nil {
-			fmt.Printf("Could not parse request: %v\n", err)
+			out.Notef("Could not parse request: %v\n", err)
 			break
 		}
 		resp, err = client.SendRequest(req)
 		if err != nil {
-			fmt.Printf("Could not send request: %v\n", err)
+			out.Notef("Could not send request: %v\n", err)
 			break
 		}
 		results = &twittergo.SearchResults{}
@@ -99,32 +194,80 @@ func main() {
 					dur = MINWAIT
 				}
 				msg := "Rate limited. Reset at %v. Waiting for %v\n"
-				fmt.Printf(msg, rle.Reset, dur)
+				out.Notef(msg, rle.Reset, dur)
 				time.Sleep(dur)
 				continue // Retry request.
 			} else {
-				fmt.Printf("Problem parsing response: %v\n", err)
+				out.Notef("Problem parsing response: %v\n", err)
 				break
 			}
 		}
-		fmt.Printf("\n")
-		for _, tweet := range results.Statuses() {
-			user := tweet.User()
-			fmt.Printf("%v.) %v\n", i, tweet.Text())
-			fmt.Printf("From %v (@%v) ", user.Name(), user.ScreenName())
-			fmt.Printf("at %v\n\n", tweet.CreatedAt().Format(time.RFC1123))
-			i += 1
+		statuses := results.Statuses()
+		if len(statuses) == 0 {
+			out.Notef("No more results, end of search.\n")
+			break
 		}
-		if query, err = results.NextQuery(); err != nil {
-			fmt.Printf("No next query: %v\n", err)
+		crossed := false
+		for _, tweet := range statuses {
+			created := tweet.CreatedAt()
+			if !until.IsZero() && !created.Before(until) {
+				continue
+			}
+			if !since.IsZero() && created.Before(since) {
+				crossed = true
+				continue
+			}
+			if export != nil {
+				err = export.Write(tweet)
+			} else {
+				coverage.Add(tweet)
+				err = out.Write(tweet)
+			}
+			if err != nil {
+				out.Notef("Could not write Tweet: %v\n", err)
+				break
+			}
+		}
+		if err != nil {
 			break
 		}
+		maxId = statuses[len(statuses)-1].Id() - 1
+		if export != nil {
+			if err = export.Save(maxId, crossed); err != nil {
+				out.Notef("Could not save checkpoint: %v\n", err)
+				break
+			}
+		}
 		if resp.HasRateLimit() {
-			fmt.Printf("Rate limit:           %v\n", resp.RateLimit())
-			fmt.Printf("Rate limit remaining: %v\n", resp.RateLimitRemaining())
-			fmt.Printf("Rate limit reset:     %v\n", resp.RateLimitReset())
+			out.Notef("Got %v Tweets, %v calls available.\n", len(statuses), resp.RateLimitRemaining())
 		} else {
-			fmt.Printf("Could not parse rate limit from response.\n")
+			out.Notef("Got %v Tweets.\n", len(statuses))
+		}
+		if crossed {
+			out.Notef("Reached %v, end of search.\n", args.Search.Since)
+			break
 		}
 	}
+	if export != nil && err == nil {
+		if err = export.Save(maxId, true); err != nil {
+			out.Notef("Could not save checkpoint: %v\n", err)
+		}
+	}
+	if err != nil {
+		if export != nil {
+			out.Notef("Wrote %v to %v before stopping.\n", coverage, args.OutputFile)
+			out.Notef("Run the same command again to resume the export.\n")
+			export.Close()
+		} else {
+			out.Notef("Found %v before stopping.\n", coverage)
+		}
+		out.Close()
+		os.Exit(1)
+	}
+	out.Notef("--------------------------------------------------------\n")
+	if export != nil {
+		out.Notef("Wrote %v to %v\n", coverage, args.OutputFile)
+	} else {
+		out.Notef("Found %v\n", coverage)
+	}
 }
@@ -0,0 +1,100 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package searchquery
+
+import (
+	"flag"
+	"strings"
+)
+
+// A flag which may be given several times, or once with commas between
+// the values.
+type listFlag struct {
+	values *[]string
+}
+
+func (f listFlag) String() string {
+	if f.values == nil {
+		return ""
+	}
+	return strings.Join(*f.values, ",")
+}
+
+func (f listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*f.values = append(*f.values, v)
+		}
+	}
+	return nil
+}
+
+// Each use of -any adds a group of comma separated alternatives.
+type groupFlag struct {
+	groups *[][]string
+}
+
+func (f groupFlag) String() string {
+	return ""
+}
+
+func (f groupFlag) Set(value string) error {
+	var group []string
+	listFlag{&group}.Set(value)
+	*f.groups = append(*f.groups, group)
+	return nil
+}
+
+// A flag which may be given several times, keeping commas.
+type repeatedFlag struct {
+	values *[]string
+}
+
+func (f repeatedFlag) String() string {
+	return ""
+}
+
+func (f repeatedFlag) Set(value string) error {
+	*f.values = append(*f.values, value)
+	return nil
+}
+
+// Defines flags on fs which fill in q, so commands can build queries from
+// the command line:
+//
+//	-term=go -phrase="happy hour" -any=cats,dogs -exclude=java
+//	-from=kurrik -to=twitterapi -mention=golang -hashtag=gophers -lang=en
+//	-since=2015-01-01 -until=2015-02-01 -media -no_retweets
+//	-geocode=37.781157,-122.398720,1mi
+//
+// Every flag but -phrase accepts comma separated values, and may be given
+// several times.  The raw query is left to the command, which usually
+// offers it as -query.
+func (q *Query) Flags(fs *flag.FlagSet) {
+	fs.Var(listFlag{&q.Terms}, "term", "Words which must appear")
+	fs.Var(repeatedFlag{&q.Phrases}, "phrase", "Exact phrase which must appear, may be repeated")
+	fs.Var(groupFlag{&q.Any}, "any", "Comma separated words of which any must appear, may be repeated")
+	fs.Var(listFlag{&q.Exclude}, "exclude", "Words which must not appear")
+	fs.Var(listFlag{&q.From}, "from", "Only Tweets from any of these screen names")
+	fs.Var(listFlag{&q.To}, "to", "Only replies to any of these screen names")
+	fs.Var(listFlag{&q.Mentions}, "mention", "Only Tweets mentioning any of these screen names")
+	fs.Var(listFlag{&q.Hashtags}, "hashtag", "Only Tweets with any of these hashtags, without the #")
+	fs.StringVar(&q.Lang, "lang", "", "Only Tweets in this language, like en")
+	fs.StringVar(&q.Since, "since", "", "Only Tweets from this date on, YYYY-MM-DD")
+	fs.StringVar(&q.Until, "until", "", "Only Tweets before this date, YYYY-MM-DD")
+	fs.BoolVar(&q.Media, "media", false, "Only Tweets with photos or videos")
+	fs.BoolVar(&q.NoRetweets, "no_retweets", false, "Leave out Retweets")
+	fs.StringVar(&q.Geocode, "geocode", "", "Only Tweets near latitude,longitude,radius like 37.78,-122.39,1mi")
+}
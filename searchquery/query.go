@@ -0,0 +1,285 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Builds queries for search/tweets.json from typed parts, and checks them
+// against the limits of the standard search API before they are sent.
+//
+//	q := &searchquery.Query{
+//	    Phrases:    []string{"happy hour"},
+//	    From:       []string{"kurrik"},
+//	    NoRetweets: true,
+//	}
+//	if err := q.Validate(); err != nil { ... }
+//	query.Set("q", q.String())  // "happy hour" from:kurrik -filter:retweets
+package searchquery
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// Longest query accepted by the standard search API, operators
+	// included.
+	MAXLENGTH = 500
+	// Twitter rejects queries it considers too complex without documenting
+	// where the line is.  This is a conservative guess at it, counting each
+	// word, phrase, operator and OR alternative once.
+	MAXOPERATORS = 25
+	// Format of the since: and until: dates.
+	DATEFORMAT = "2006-01-02"
+)
+
+var (
+	screenNamePattern = regexp.MustCompile(`^[A-Za-z0-9_]{1,15}$`)
+	langPattern       = regexp.MustCompile(`^[a-z]{2,3}(-[a-z]+)?$`)
+	geocodePattern    = regexp.MustCompile(`^(-?[0-9.]+),(-?[0-9.]+),([0-9.]+)(mi|km)$`)
+)
+
+// The parts of a search.  Every part must match, and blank parts are left
+// out.
+type Query struct {
+	// Words which must appear, in any order.
+	Terms []string
+	// Phrases which must appear exactly.
+	Phrases []string
+	// Groups of words where any one of each group must appear.
+	Any [][]string
+	// Words which must not appear.
+	Exclude []string
+	// Screen names, without the @.  Tweets must be from, replying to or
+	// mentioning one of each list.
+	From     []string
+	To       []string
+	Mentions []string
+	// Hashtags, without the #.
+	Hashtags []string
+	// Language code, like "en".
+	Lang string
+	// Dates as YYYY-MM-DD.  Until is exclusive.
+	Since string
+	Until string
+	// Only Tweets with photos or videos.
+	Media bool
+	// Leave out Retweets.
+	NoRetweets bool
+	// Only Tweets near "latitude,longitude,radius", radius in mi or km,
+	// like "37.781157,-122.398720,1mi".
+	Geocode string
+	// Appended as is, for operators not covered above.
+	Raw string
+}
+
+// Joins names into "op:a" or "(op:a OR op:b)".
+func alternatives(prefix string, names []string) string {
+	parts := make([]string, len(names))
+	for i, name := range names {
+		parts[i] = prefix + name
+	}
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return "(" + strings.Join(parts, " OR ") + ")"
+}
+
+func (q *Query) clauses() (clauses []string) {
+	clauses = append(clauses, q.Terms...)
+	for _, phrase := range q.Phrases {
+		// Search has no escapes, so quotes inside the phrase are dropped.
+		clauses = append(clauses, "\""+strings.Replace(phrase, "\"", "", -1)+"\"")
+	}
+	for _, group := range q.Any {
+		if len(group) > 0 {
+			clauses = append(clauses, alternatives("", group))
+		}
+	}
+	for _, word := range q.Exclude {
+		clauses = append(clauses, "-"+word)
+	}
+	for _, op := range []struct {
+		prefix string
+		names  []string
+	}{
+		{"from:", q.From},
+		{"to:", q.To},
+		{"@", q.Mentions},
+		{"#", q.Hashtags},
+	} {
+		if len(op.names) > 0 {
+			clauses = append(clauses, alternatives(op.prefix, op.names))
+		}
+	}
+	if q.Lang != "" {
+		clauses = append(clauses, "lang:"+q.Lang)
+	}
+	if q.Since != "" {
+		clauses = append(clauses, "since:"+q.Since)
+	}
+	if q.Until != "" {
+		clauses = append(clauses, "until:"+q.Until)
+	}
+	if q.Media {
+		clauses = append(clauses, "filter:media")
+	}
+	if q.NoRetweets {
+		clauses = append(clauses, "-filter:retweets")
+	}
+	if q.Geocode != "" {
+		clauses = append(clauses, "geocode:"+q.Geocode)
+	}
+	if q.Raw != "" {
+		clauses = append(clauses, q.Raw)
+	}
+	return
+}
+
+// Returns the query to pass as q.
+func (q *Query) String() string {
+	return strings.Join(q.clauses(), " ")
+}
+
+// Returns true if no part of the query is set.
+func (q *Query) Empty() bool {
+	return len(q.clauses()) == 0
+}
+
+// Counts the parts of the query towards MAXOPERATORS.
+func (q *Query) Operators() (count int) {
+	count = len(q.Terms) + len(q.Phrases) + len(q.Exclude) +
+		len(q.From) + len(q.To) + len(q.Mentions) + len(q.Hashtags) +
+		len(strings.Fields(q.Raw))
+	for _, group := range q.Any {
+		count += len(group)
+	}
+	for _, set := range []bool{q.Lang != "", q.Since != "", q.Until != "", q.Media, q.NoRetweets, q.Geocode != ""} {
+		if set {
+			count++
+		}
+	}
+	return
+}
+
+func checkWords(kind string, words []string) error {
+	for _, word := range words {
+		if word == "" || strings.ContainsAny(word, " \t\n\"") {
+			return fmt.Errorf("%v %q must be a single word, use a phrase for several", kind, word)
+		}
+		if strings.HasPrefix(word, "-") {
+			return fmt.Errorf("%v %q must not start with -, use an exclusion", kind, word)
+		}
+	}
+	return nil
+}
+
+func checkNames(kind string, names []string) error {
+	for _, name := range names {
+		if !screenNamePattern.MatchString(name) {
+			return fmt.Errorf("%v %q is not a screen name, leave out the @", kind, name)
+		}
+	}
+	return nil
+}
+
+func checkDate(kind string, date string) (t time.Time, err error) {
+	if date == "" {
+		return
+	}
+	if t, err = time.Parse(DATEFORMAT, date); err != nil {
+		err = fmt.Errorf("%v %q is not a YYYY-MM-DD date", kind, date)
+	}
+	return
+}
+
+func checkGeocode(geocode string) error {
+	m := geocodePattern.FindStringSubmatch(geocode)
+	if m == nil {
+		return fmt.Errorf("Geocode %q is not latitude,longitude,radius with radius in mi or km", geocode)
+	}
+	lat, err1 := strconv.ParseFloat(m[1], 64)
+	long, err2 := strconv.ParseFloat(m[2], 64)
+	if err1 != nil || err2 != nil || lat < -90 || lat > 90 || long < -180 || long > 180 {
+		return fmt.Errorf("Geocode %q is not a valid latitude and longitude", geocode)
+	}
+	return nil
+}
+
+// Checks that every part is well formed and that the query fits the limits
+// of the search API.
+func (q *Query) Validate() (err error) {
+	var since, until time.Time
+	if q.Empty() {
+		return fmt.Errorf("Query is empty")
+	}
+	if err = checkWords("Term", q.Terms); err != nil {
+		return
+	}
+	if err = checkWords("Excluded word", q.Exclude); err != nil {
+		return
+	}
+	for _, group := range q.Any {
+		if err = checkWords("Alternative", group); err != nil {
+			return
+		}
+	}
+	for _, phrase := range q.Phrases {
+		if strings.TrimSpace(phrase) == "" || strings.Contains(phrase, "\"") {
+			return fmt.Errorf("Phrase %q must not be blank or contain quotes", phrase)
+		}
+	}
+	if err = checkNames("From", q.From); err != nil {
+		return
+	}
+	if err = checkNames("To", q.To); err != nil {
+		return
+	}
+	if err = checkNames("Mention", q.Mentions); err != nil {
+		return
+	}
+	if err = checkWords("Hashtag", q.Hashtags); err != nil {
+		return
+	}
+	for _, tag := range q.Hashtags {
+		if strings.HasPrefix(tag, "#") {
+			return fmt.Errorf("Hashtag %q must not start with #", tag)
+		}
+	}
+	if q.Lang != "" && !langPattern.MatchString(q.Lang) {
+		return fmt.Errorf("Language %q is not a language code like en", q.Lang)
+	}
+	if since, err = checkDate("Since", q.Since); err != nil {
+		return
+	}
+	if until, err = checkDate("Until", q.Until); err != nil {
+		return
+	}
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		return fmt.Errorf("Since %v must be before until %v", q.Since, q.Until)
+	}
+	if q.Geocode != "" {
+		if err = checkGeocode(q.Geocode); err != nil {
+			return
+		}
+	}
+	if n := utf8.RuneCountInString(q.String()); n > MAXLENGTH {
+		return fmt.Errorf("Query is %v characters long, the limit is %v", n, MAXLENGTH)
+	}
+	if n := q.Operators(); n > MAXOPERATORS {
+		return fmt.Errorf("Query has %v words and operators, more than %v is likely too complex", n, MAXOPERATORS)
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package searchquery
+
+import (
+	"flag"
+	"strings"
+	"testing"
+)
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		query Query
+		want  string
+	}{
+		{Query{}, ""},
+		{Query{Terms: []string{"go", "gopher"}}, "go gopher"},
+		{Query{Phrases: []string{"happy hour"}}, `"happy hour"`},
+		{Query{Phrases: []string{`C:\go`}}, `"C:\go"`},
+		{Query{Phrases: []string{"café ☕"}}, `"café ☕"`},
+		{Query{Phrases: []string{`say "hi" there`}}, `"say hi there"`},
+		{Query{Any: [][]string{{"cats", "dogs"}, {"fish"}}}, "(cats OR dogs) fish"},
+		{Query{Exclude: []string{"java"}}, "-java"},
+		{Query{From: []string{"kurrik"}, To: []string{"a", "b"}}, "from:kurrik (to:a OR to:b)"},
+		{Query{Mentions: []string{"golang"}, Hashtags: []string{"gophers"}}, "@golang #gophers"},
+		{Query{Lang: "en", Since: "2015-01-01", Until: "2015-02-01"}, "lang:en since:2015-01-01 until:2015-02-01"},
+		{Query{Media: true, NoRetweets: true}, "filter:media -filter:retweets"},
+		{Query{Geocode: "37.78,-122.39,1mi", Raw: "min_faves:10"}, "geocode:37.78,-122.39,1mi min_faves:10"},
+		{
+			Query{Terms: []string{"go"}, Phrases: []string{"happy hour"}, From: []string{"kurrik"}, NoRetweets: true},
+			`go "happy hour" from:kurrik -filter:retweets`,
+		},
+	}
+	for _, test := range tests {
+		if got := test.query.String(); got != test.want {
+			t.Errorf("%+v.String() = %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestOperators(t *testing.T) {
+	tests := []struct {
+		query Query
+		want  int
+	}{
+		{Query{}, 0},
+		{Query{Terms: []string{"a", "b"}, Phrases: []string{"c d"}}, 3},
+		{Query{Any: [][]string{{"a", "b"}, {"c"}}}, 3},
+		{Query{Lang: "en", Media: true, Raw: "min_faves:10 min_retweets:5"}, 4},
+	}
+	for _, test := range tests {
+		if got := test.query.Operators(); got != test.want {
+			t.Errorf("%+v.Operators() = %v, want %v", test.query, got, test.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	many := make([]string, MAXOPERATORS+1)
+	for i := range many {
+		many[i] = "w"
+	}
+	tests := []struct {
+		name  string
+		query Query
+		err   string
+	}{
+		{"valid", Query{Terms: []string{"go"}, From: []string{"kurrik"}, Since: "2015-01-01", Until: "2015-02-01"}, ""},
+		{"valid geocode", Query{Geocode: "37.781157,-122.398720,1mi"}, ""},
+		{"valid lang", Query{Terms: []string{"go"}, Lang: "zh-tw"}, ""},
+		{"empty", Query{}, "empty"},
+		{"term with space", Query{Terms: []string{"two words"}}, "single word"},
+		{"term with dash", Query{Terms: []string{"-java"}}, "exclusion"},
+		{"blank phrase", Query{Phrases: []string{" "}}, "blank"},
+		{"quoted phrase", Query{Phrases: []string{`say "hi"`}}, "quotes"},
+		{"alternative with quote", Query{Any: [][]string{{`a"b`}}}, "single word"},
+		{"screen name with @", Query{From: []string{"@kurrik"}}, "leave out the @"},
+		{"long screen name", Query{To: []string{"abcdefghijklmnop"}}, "not a screen name"},
+		{"hashtag with #", Query{Hashtags: []string{"#go"}}, "must not start with #"},
+		{"language", Query{Terms: []string{"go"}, Lang: "English"}, "language code"},
+		{"date", Query{Terms: []string{"go"}, Since: "01/02/2015"}, "YYYY-MM-DD"},
+		{"date order", Query{Terms: []string{"go"}, Since: "2015-02-01", Until: "2015-01-01"}, "must be before"},
+		{"same dates", Query{Terms: []string{"go"}, Since: "2015-01-01", Until: "2015-01-01"}, "must be before"},
+		{"geocode format", Query{Geocode: "37.78,-122.39"}, "radius"},
+		{"geocode range", Query{Geocode: "91,0,1km"}, "valid latitude"},
+		{"too long", Query{Phrases: []string{strings.Repeat("x", MAXLENGTH)}}, "characters long"},
+		{"too complex", Query{Terms: many}, "too complex"},
+	}
+	for _, test := range tests {
+		err := test.query.Validate()
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%v: Validate() = %v, want no error", test.name, err)
+		case test.err != "" && err == nil:
+			t.Errorf("%v: Validate() = nil, want an error about %q", test.name, test.err)
+		case err != nil && !strings.Contains(err.Error(), test.err):
+			t.Errorf("%v: Validate() = %v, want an error about %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestFlags(t *testing.T) {
+	tests := []struct {
+		args []string
+		want string
+	}{
+		{[]string{"-term=go,gopher", "-term=golang"}, "go gopher golang"},
+		{[]string{"-phrase=happy hour", "-phrase=a, b"}, `"happy hour" "a, b"`},
+		{[]string{"-any=cats,dogs", "-any=fish"}, "(cats OR dogs) fish"},
+		{[]string{"-from=kurrik, twitterapi", "-no_retweets"}, "(from:kurrik OR from:twitterapi) -filter:retweets"},
+		{[]string{"-mention=golang", "-hashtag=gophers", "-lang=en", "-media"}, "@golang #gophers lang:en filter:media"},
+	}
+	for _, test := range tests {
+		var q Query
+		fs := flag.NewFlagSet("test", flag.ContinueOnError)
+		q.Flags(fs)
+		if err := fs.Parse(test.args); err != nil {
+			t.Fatalf("Parse(%q): %v", test.args, err)
+		}
+		if got := q.String(); got != test.want {
+			t.Errorf("Flags %q built %q, want %q", test.args, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,362 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Shows trending topics and the locations they are available for.
+package main
+
+// Locations are identified by their Yahoo! WOEID, 1 being the whole world.
+// List the locations with trends, or those closest to a point:
+//   $ go run ./trends available
+//   $ go run ./trends -lat=37.7821 -long=-122.4093 closest
+//   1.) San Francisco, United States (Town)
+//       WOEID: 2487956
+//
+// Show the trends of a location:
+//   $ go run ./trends -id=2487956 place
+//
+// Or fetch them every -interval, appending each fetch to the -history file
+// as one JSON object per line and printing the trends which are new since
+// the previous fetch, even one from an earlier run:
+//   $ go run ./trends -id=2487956 -interval=15m -history=sf_trends.ndjson watch
+//   2015-03-08T17:00:00Z: 50 trends, new: #SXSW, Daylight Saving Time
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/kurrik/oauth1a"
+	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const MINWAIT = time.Duration(10) * time.Second
+
+func LoadCredentials() (client *twittergo.Client, err error) {
+	credentials, err := ioutil.ReadFile("CREDENTIALS")
+	if err != nil {
+		return
+	}
+	lines := strings.Split(string(credentials), "\n")
+	config := &oauth1a.ClientConfig{
+		ConsumerKey:    lines[0],
+		ConsumerSecret: lines[1],
+	}
+	user := oauth1a.NewAuthorizedConfig(lines[2], lines[3])
+	client = twittergo.NewClient(config, user)
+	return
+}
+
+type Args struct {
+	Command  string
+	Id       string
+	Lat      string
+	Long     string
+	Exclude  bool
+	Interval time.Duration
+	History  string
+	Output   string
+	Template string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Id, "id", "1", "WOEID of the location, 1 for worldwide")
+	flag.StringVar(&a.Lat, "lat", "", "Latitude for closest")
+	flag.StringVar(&a.Long, "long", "", "Longitude for closest")
+	flag.BoolVar(&a.Exclude, "exclude_hashtags", false, "Leave out trending hashtags")
+	flag.DurationVar(&a.Interval, "interval", 15*time.Minute, "Time between fetches when watching")
+	flag.StringVar(&a.History, "history", "trends_history.ndjson", "File watch appends every fetch to")
+	flag.StringVar(&a.Output, "output", output.Text, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
+	flag.Parse()
+	a.Command = flag.Arg(0)
+	return a
+}
+
+// A location trends are available for.
+type Location map[string]interface{}
+
+type Locations []Location
+
+// A trending topic.
+type Trend map[string]interface{}
+
+func asMap(record interface{}) map[string]interface{} {
+	switch r := record.(type) {
+	case Location:
+		return r
+	case Trend:
+		return r
+	}
+	return nil
+}
+
+func column(name string, key string) output.Column {
+	return output.Column{
+		Name: name,
+		Value: func(record interface{}) string {
+			return output.Value(asMap(record), key)
+		},
+	}
+}
+
+var LocationSchema = &output.Schema{
+	Columns: []output.Column{
+		column("woeid", "woeid"),
+		column("name", "name"),
+		column("country", "country"),
+		column("country_code", "countryCode"),
+		{
+			Name: "place_type",
+			Value: func(record interface{}) string {
+				return output.Value(output.Object(asMap(record), "placeType"), "name")
+			},
+		},
+	},
+	Text: func(w io.Writer, i int, record interface{}) (err error) {
+		l := asMap(record)
+		_, err = fmt.Fprintf(w, "%v.) %v, %v (%v)\n    WOEID: %v\n", i, output.Value(l, "name"),
+			output.Value(l, "country"), output.Value(output.Object(l, "placeType"), "name"),
+			output.Value(l, "woeid"))
+		return
+	},
+}
+
+var TrendSchema = &output.Schema{
+	Columns: []output.Column{
+		column("name", "name"),
+		column("tweet_volume", "tweet_volume"),
+		column("query", "query"),
+		column("url", "url"),
+	},
+	Text: func(w io.Writer, i int, record interface{}) (err error) {
+		t := asMap(record)
+		volume := output.Value(t, "tweet_volume")
+		if volume == "" {
+			volume = "unknown"
+		}
+		_, err = fmt.Fprintf(w, "%v.) %v (%v Tweets)\n", i, output.Value(t, "name"), volume)
+		return
+	},
+}
+
+// Sleeps until a rate limit resets.  Returns any other error unchanged.
+func handleRateLimit(err error) error {
+	if rle, ok := err.(twittergo.RateLimitError); ok {
+		dur := rle.Reset.Sub(time.Now()) + time.Second
+		if dur < MINWAIT {
+			// Don't wait less than minwait.
+			dur = MINWAIT
+		}
+		fmt.Fprintf(os.Stderr, "Rate limited. Reset at %v. Waiting for %v\n", rle.Reset, dur)
+		time.Sleep(dur)
+		return nil
+	}
+	return err
+}
+
+func get(client *twittergo.Client, path string, params url.Values, result interface{}) (err error) {
+	var (
+		req  *http.Request
+		resp *twittergo.APIResponse
+	)
+	for {
+		if req, err = http.NewRequest("GET", fmt.Sprintf("%v?%v", path, params.Encode()), nil); err != nil {
+			return fmt.Errorf("Could not parse request: %v", err)
+		}
+		if resp, err = client.SendRequest(req); err != nil {
+			return fmt.Errorf("Could not send request: %v", err)
+		}
+		if err = resp.Parse(result); err != nil {
+			if err = handleRateLimit(err); err != nil {
+				return fmt.Errorf("Problem parsing response: %v", err)
+			}
+			continue
+		}
+		return
+	}
+}
+
+// The trends of a location at one time, as returned by trends/place.json.
+// Watch writes these to the history with FetchedAt added.
+type TrendsResult map[string]interface{}
+
+func (r TrendsResult) Trends() (trends []Trend) {
+	for _, item := range output.Array(r, "trends") {
+		if t, ok := item.(map[string]interface{}); ok {
+			trends = append(trends, Trend(t))
+		}
+	}
+	return
+}
+
+func fetchTrends(client *twittergo.Client, args *Args) (result TrendsResult, err error) {
+	results := []TrendsResult{}
+	params := url.Values{}
+	params.Set("id", args.Id)
+	if args.Exclude {
+		params.Set("exclude", "hashtags")
+	}
+	if err = get(client, "/1.1/trends/place.json", params, &results); err != nil {
+		return
+	}
+	if len(results) == 0 {
+		err = fmt.Errorf("No trends for WOEID %v", args.Id)
+		return
+	}
+	return results[0], nil
+}
+
+// Returns the names of the trends in the last line of the history file.
+func lastTrends(path string) (names map[string]bool, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	last := lines[len(lines)-1]
+	if last == "" {
+		return
+	}
+	result := TrendsResult{}
+	if err = json.Unmarshal([]byte(last), &result); err != nil {
+		return nil, fmt.Errorf("Could not read %v: %v", path, err)
+	}
+	names = map[string]bool{}
+	for _, t := range result.Trends() {
+		names[output.Value(t, "name")] = true
+	}
+	return
+}
+
+// Fetches the trends every -interval, appending them to the history.
+func watch(client *twittergo.Client, args *Args) (err error) {
+	var (
+		history  *os.File
+		previous map[string]bool
+		result   TrendsResult
+		line     []byte
+	)
+	if previous, err = lastTrends(args.History); err != nil {
+		return
+	}
+	if history, err = os.OpenFile(args.History, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644); err != nil {
+		return
+	}
+	defer history.Close()
+	for ; ; time.Sleep(args.Interval) {
+		if result, err = fetchTrends(client, args); err != nil {
+			fmt.Fprintf(os.Stderr, "Could not fetch trends, trying again in %v: %v\n", args.Interval, err)
+			continue
+		}
+		result["fetched_at"] = time.Now().UTC().Format(time.RFC3339)
+		if line, err = json.Marshal(result); err != nil {
+			return
+		}
+		if _, err = fmt.Fprintf(history, "%s\n", line); err != nil {
+			return
+		}
+		names := map[string]bool{}
+		added := []string{}
+		for _, t := range result.Trends() {
+			name := output.Value(t, "name")
+			names[name] = true
+			if previous != nil && !previous[name] {
+				added = append(added, name)
+			}
+		}
+		msg := fmt.Sprintf("%v: %v trends", output.Value(result, "as_of"), len(names))
+		if len(added) > 0 {
+			msg += ", new: " + strings.Join(added, ", ")
+		}
+		fmt.Println(msg)
+		previous = names
+	}
+}
+
+func main() {
+	var (
+		err       error
+		client    *twittergo.Client
+		args      *Args
+		out       *output.Writer
+		locations Locations
+		result    TrendsResult
+	)
+	args = parseArgs()
+	if client, err = LoadCredentials(); err != nil {
+		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+		os.Exit(1)
+	}
+	switch args.Command {
+	case "available", "closest":
+		path := "/1.1/trends/available.json"
+		params := url.Values{}
+		if args.Command == "closest" {
+			if args.Lat == "" || args.Long == "" {
+				fmt.Printf("Specify -lat and -long.\n")
+				os.Exit(1)
+			}
+			path = "/1.1/trends/closest.json"
+			params.Set("lat", args.Lat)
+			params.Set("long", args.Long)
+		}
+		if out, err = output.NewWriter(os.Stdout, args.Output, args.Template, LocationSchema); err != nil {
+			break
+		}
+		if err = get(client, path, params, &locations); err != nil {
+			break
+		}
+		for _, l := range locations {
+			if err = out.Write(l); err != nil {
+				break
+			}
+		}
+	case "", "place":
+		if out, err = output.NewWriter(os.Stdout, args.Output, args.Template, TrendSchema); err != nil {
+			break
+		}
+		if result, err = fetchTrends(client, args); err != nil {
+			break
+		}
+		out.Notef("Trends as of %v:\n", output.Value(result, "as_of"))
+		for _, t := range result.Trends() {
+			if err = out.Write(t); err != nil {
+				break
+			}
+		}
+	case "watch":
+		err = watch(client, args)
+	default:
+		err = fmt.Errorf("Unknown command %v, use available, closest, place or watch", args.Command)
+	}
+	if out != nil {
+		// Also when the command failed, so json output is still closed.
+		out.Close()
+	}
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+}
@@ -15,9 +15,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/kurrik/oauth1a"
 	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/twittertext"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -48,14 +50,22 @@ func main() {
 		req    *http.Request
 		resp   *twittergo.APIResponse
 		tweet  *twittergo.Tweet
+		status *twittertext.Result
 	)
+	text := flag.String("status", fmt.Sprintf("Hello %v!", time.Now()), "Text of the Tweet")
+	flag.Parse()
 	client, err = LoadCredentials()
 	if err != nil {
 		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
 		os.Exit(1)
 	}
+	if status, err = twittertext.Validate(*text); err != nil {
+		fmt.Printf("Could not post Tweet: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Length:               %v\n", status)
 	data := url.Values{}
-	data.Set("status", fmt.Sprintf("Hello %v!", time.Now()))
+	data.Set("status", status.Text)
 	body := strings.NewReader(data.Encode())
 	req, err = http.NewRequest("POST", "/1.1/statuses/update.json", body)
 	if err != nil {
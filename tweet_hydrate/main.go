@@ -17,11 +17,11 @@ package main
 
 import (
 	"bufio"
-	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/kurrik/oauth1a"
 	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -48,12 +48,16 @@ func LoadCredentials() (client *twittergo.Client, err error) {
 type Args struct {
 	InputFile  string
 	OutputFile string
+	Output     string
+	Template   string
 }
 
 func parseArgs() *Args {
 	a := &Args{}
 	flag.StringVar(&a.InputFile, "in", "tweet_ids.tsv", "Input file")
-	flag.StringVar(&a.OutputFile, "out", "hydrated.tsv", "Output file")
+	flag.StringVar(&a.OutputFile, "out", "hydrated.json", "Output file")
+	flag.StringVar(&a.Output, "output", output.NDJSON, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
 	flag.Parse()
 	return a
 }
@@ -92,7 +96,7 @@ func main() {
 		in       *os.File
 		query    url.Values
 		results  TweetMapMap
-		text     []byte
+		w        *output.Writer
 		scanner  *bufio.Scanner
 		endpoint string
 	)
@@ -112,6 +116,11 @@ func main() {
 		os.Exit(1)
 	}
 	defer out.Close()
+	if w, err = output.NewWriter(out, args.Output, args.Template, output.TweetSchema); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	defer w.Close()
 	const (
 		count   int = 100
 		urltmpl     = "/1.1/statuses/lookup.json?%v"
@@ -124,16 +133,19 @@ func main() {
 	for {
 		if ids, err = getIds(scanner, count); err != nil {
 			fmt.Printf("Problem reading IDs: %v\n", err)
+			w.Close()
 			os.Exit(1)
 		}
 		query.Set("id", ids)
 		endpoint = fmt.Sprintf(urltmpl, query.Encode())
 		if req, err = http.NewRequest("GET", endpoint, nil); err != nil {
 			fmt.Printf("Could not parse request: %v\n", err)
+			w.Close()
 			os.Exit(1)
 		}
 		if resp, err = client.SendRequest(req); err != nil {
 			fmt.Printf("Could not send request: %v\n", err)
+			w.Close()
 			os.Exit(1)
 		}
 		results = TweetMapMap{}
@@ -157,17 +169,21 @@ func main() {
 			fmt.Printf("No more results, end of list.\n")
 			break
 		}
-		for id, tweet := range results.Id {
-			if text, err = json.Marshal(tweet); err != nil {
-				fmt.Printf("Could not encode Tweet: %v\n", err)
+		missing := 0
+		for _, tweet := range results.Id {
+			if tweet == nil {
+				// Deleted or protected, the map has null for these.
+				missing += 1
+				continue
+			}
+			if err = w.Write(tweet); err != nil {
+				fmt.Printf("Could not write Tweet: %v\n", err)
+				w.Close()
 				os.Exit(1)
 			}
-			out.Write([]byte(fmt.Sprintf("%v\t", id)))
-			out.Write(text)
-			out.Write([]byte("\n"))
 			total += 1
 		}
-		fmt.Printf("Got %v Tweets, %v total", batch, total)
+		fmt.Printf("Got %v Tweets, %v unavailable, %v total", batch-missing, missing, total)
 		if resp.HasRateLimit() {
 			fmt.Printf(", %v calls available", resp.RateLimitRemaining())
 		}
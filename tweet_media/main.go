@@ -12,18 +12,32 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
+// Posts a Tweet with up to four images, each with alt text.
 package main
 
+// The images are uploaded to media/upload.json first, then attached in
+// order.  The n-th -alt describes the n-th image:
+//   $ go run ./tweet_media -media=chart.png,team.jpg -alt="Sales by month" -alt="The team"
+//
+// With -prepare, large images are scaled down to -max_dimension and
+// re-encoded, and EXIF metadata such as GPS locations is removed first:
+//   $ go run ./tweet_media -prepare -media=IMG_0042.png
+//   Prepared:             IMG_0042.png: resized from 6000x4000 to 4096x2730, ...
+
 import (
-	"bytes"
+	"context"
+	"flag"
 	"fmt"
 	"github.com/kurrik/oauth1a"
 	"github.com/kurrik/twittergo"
-	"io"
+	"github.com/kurrik/twittergo-examples/mediaupload"
+	"github.com/kurrik/twittergo-examples/publish"
+	"github.com/kurrik/twittergo-examples/twittertext"
 	"io/ioutil"
-	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
 	"strings"
 	"time"
 )
@@ -43,56 +57,88 @@ func LoadCredentials() (client *twittergo.Client, err error) {
 	return
 }
 
-func GetBody() (body io.ReadWriter, header string, err error) {
-	var (
-		mp     *multipart.Writer
-		media  []byte
-		writer io.Writer
-	)
-	body = bytes.NewBufferString("")
-	mp = multipart.NewWriter(body)
-	media, err = ioutil.ReadFile("tweet_media/media.png")
-	if err != nil {
-		return
-	}
-	mp.WriteField("status", fmt.Sprintf("Hello %v!", time.Now()))
-	writer, err = mp.CreateFormFile("media[]", "media.png")
-	if err != nil {
-		return
+type Args struct {
+	Status string
+	Media  publish.MediaFlags
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Status, "status", fmt.Sprintf("Hello %v!", time.Now()), "Text of the Tweet")
+	a.Media.Flags(flag.CommandLine)
+	flag.Parse()
+	if len(a.Media.Paths) == 0 {
+		a.Media.Paths = []string{"tweet_media/media.png"}
 	}
-	writer.Write(media)
-	header = fmt.Sprintf("multipart/form-data;boundary=%v", mp.Boundary())
-	mp.Close()
-	return
+	return a
 }
 
 func main() {
 	var (
-		err    error
-		client *twittergo.Client
-		req    *http.Request
-		resp   *twittergo.APIResponse
-		tweet  *twittergo.Tweet
+		err     error
+		client  *twittergo.Client
+		req     *http.Request
+		resp    *twittergo.APIResponse
+		tweet   *twittergo.Tweet
+		media   []publish.Media
+		mediaId string
+		params  url.Values
+		result  *twittertext.Result
 	)
+	args := parseArgs()
 	client, err = LoadCredentials()
 	if err != nil {
 		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
 		os.Exit(1)
 	}
-
-	body, header, err := GetBody()
+	if result, err = twittertext.Validate(args.Status); err != nil {
+		fmt.Printf("Could not post Tweet: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Length:               %v\n", result)
+	if media, err = args.Media.Media(); err != nil {
+		fmt.Printf("Could not attach media: %v\n", err)
+		os.Exit(1)
+	}
+	prepared, cleanup, err := publish.PrepareMedia(media, args.Media.Options(), func(p *mediaupload.Prepared) {
+		fmt.Printf("Prepared:             %v\n", p)
+	})
 	if err != nil {
-		fmt.Printf("Problem loading body: %v\n", err)
+		fmt.Printf("Could not prepare media: %v\n", err)
+		os.Exit(1)
+	}
+	if err = publish.CheckMedia(prepared); err != nil {
+		cleanup()
+		fmt.Printf("Could not attach media: %v\n", err)
 		os.Exit(1)
 	}
 
-	endpoint := "/1.1/statuses/update_with_media.json"
-	req, err = http.NewRequest("POST", endpoint, body)
+	// Ctrl-C stops the upload in progress.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	status := &publish.Status{Text: result.Text}
+	for i, m := range prepared {
+		if mediaId, err = publish.UploadMedia(ctx, client, m); err != nil {
+			cleanup()
+			fmt.Printf("Could not upload %v: %v\n", media[i].Path, publish.Describe(err))
+			os.Exit(1)
+		}
+		fmt.Printf("Media:                %v as %v\n", media[i].Path, mediaId)
+		status.MediaIds = append(status.MediaIds, mediaId)
+	}
+	cleanup()
+	if params, err = status.Values(); err != nil {
+		fmt.Printf("Could not post Tweet: %v\n", err)
+		os.Exit(1)
+	}
+
+	endpoint := "/1.1/statuses/update.json"
+	req, err = http.NewRequest("POST", endpoint, strings.NewReader(params.Encode()))
 	if err != nil {
 		fmt.Printf("Could not parse request: %v\n", err)
 		os.Exit(1)
 	}
-	req.Header.Set("Content-Type", header)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
 
 	resp, err = client.SendRequest(req)
 	if err != nil {
@@ -102,24 +148,17 @@ func main() {
 	tweet = &twittergo.Tweet{}
 	err = resp.Parse(tweet)
 	if err != nil {
-		fmt.Printf("Problem parsing response: %v\n", err)
+		fmt.Printf("Problem parsing response: %v\n", publish.Describe(err))
 		os.Exit(1)
 	}
-	fmt.Printf("ID:                         %v\n", tweet.Id())
-	fmt.Printf("Tweet:                      %v\n", tweet.Text())
-	fmt.Printf("User:                       %v\n", tweet.User().Name())
+	fmt.Printf("ID:                   %v\n", tweet.Id())
+	fmt.Printf("Tweet:                %v\n", tweet.Text())
+	fmt.Printf("User:                 %v\n", tweet.User().Name())
 	if resp.HasRateLimit() {
-		fmt.Printf("Rate limit:                 %v\n", resp.RateLimit())
-		fmt.Printf("Rate limit remaining:       %v\n", resp.RateLimitRemaining())
-		fmt.Printf("Rate limit reset:           %v\n", resp.RateLimitReset())
+		fmt.Printf("Rate limit:           %v\n", resp.RateLimit())
+		fmt.Printf("Rate limit remaining: %v\n", resp.RateLimitRemaining())
+		fmt.Printf("Rate limit reset:     %v\n", resp.RateLimitReset())
 	} else {
 		fmt.Printf("Could not parse rate limit from response.\n")
 	}
-	if resp.HasMediaRateLimit() {
-		fmt.Printf("Media Rate limit:           %v\n", resp.MediaRateLimit())
-		fmt.Printf("Media Rate limit remaining: %v\n", resp.MediaRateLimitRemaining())
-		fmt.Printf("Media Rate limit reset:     %v\n", resp.MediaRateLimitReset())
-	} else {
-		fmt.Printf("Could not parse media rate limit from response.\n")
-	}
 }
@@ -15,9 +15,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/kurrik/oauth1a"
 	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/places"
+	"github.com/kurrik/twittergo-examples/twittertext"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -41,6 +44,21 @@ func LoadCredentials() (client *twittergo.Client, err error) {
 	return
 }
 
+type Args struct {
+	Status  string
+	Place   string
+	PlaceId string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Status, "status", fmt.Sprintf("Fakekurrik-Fakekurrik %v", time.Now()), "Text of the Tweet")
+	flag.StringVar(&a.Place, "place", "", "Name or latitude,longitude of the place, overrides -place_id")
+	flag.StringVar(&a.PlaceId, "place_id", "15cbc94209abe896", "ID of the place")
+	flag.Parse()
+	return a
+}
+
 func main() {
 	var (
 		err    error
@@ -48,15 +66,31 @@ func main() {
 		req    *http.Request
 		resp   *twittergo.APIResponse
 		tweet  *twittergo.Tweet
+		place  places.Place
+		status *twittertext.Result
 	)
+	args := parseArgs()
 	client, err = LoadCredentials()
 	if err != nil {
 		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
 		os.Exit(1)
 	}
+	if status, err = twittertext.Validate(args.Status); err != nil {
+		fmt.Printf("Could not post Tweet: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Length:               %v\n", status)
+	if args.Place != "" {
+		if place, err = places.Resolve(client, args.Place); err != nil {
+			fmt.Printf("Could not find place: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Place:                %v (%v)\n", place.FullName(), place.Id())
+		args.PlaceId = place.Id()
+	}
 	data := url.Values{}
-	data.Set("status", fmt.Sprintf("Fakekurrik-Fakekurrik %v", time.Now()))
-	data.Set("place_id", "15cbc94209abe896")
+	data.Set("status", status.Text)
+	data.Set("place_id", args.PlaceId)
 	body := strings.NewReader(data.Encode())
 	req, err = http.NewRequest("POST", "/1.1/statuses/update.json", body)
 	if err != nil {
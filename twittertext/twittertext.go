@@ -0,0 +1,255 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Counts the length of Tweet text the way Twitter does, so overlong Tweets
+// are caught before they are sent to statuses/update.json.
+//
+// Text is NFC normalized first.  Latin and most punctuation weigh one
+// character, while CJK and most other scripts weigh two.  An emoji weighs
+// two however many code points make it up, and every URL counts as the 23
+// characters of its t.co link.
+//
+//	r := twittertext.Parse("Hello 世界 https://example.com/a/long/path")
+//	r.WeightedLength  // 34
+//	r.Remaining()     // 246
+//	if err := r.Err(); err != nil { ... }
+package twittertext
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+const (
+	// Longest weighted length of a Tweet.
+	MAXLENGTH = 280
+	// Weights are in hundredths of a character.
+	SCALE = 100
+	// Weight of code points outside of the light ranges, and of emoji.
+	DEFAULTWEIGHT = 200
+	// Length every URL counts as, however long it really is.
+	URLLENGTH = 23
+)
+
+// Code points weighing one character, SCALE each.  Everything else weighs
+// DEFAULTWEIGHT.
+var lightRanges = [][2]rune{
+	{0x0000, 0x10FF}, // Latin through Georgian
+	{0x2000, 0x200D}, // Spaces and joiners
+	{0x2010, 0x201F}, // Dashes and quotes
+	{0x2032, 0x2037}, // Primes
+}
+
+// Code points Twitter refuses in a Tweet.
+var invalidChars = "\uFFFE\uFEFF\uFFFF"
+
+// URLs with a scheme or a www., and bare domains under common TLDs.
+var urlPattern = regexp.MustCompile(`(?i)\b(?:https?://[^\s<>"]+|www\.[^\s<>"]+|` +
+	`[a-z0-9][a-z0-9-]*(?:\.[a-z0-9-]+)*\.(?:com|net|org|edu|gov|io|co|me|ly|dev|app|info|us|uk|de|fr|jp)\b(?:/[^\s<>"]*)?)`)
+
+// Punctuation ending a sentence rather than a URL.
+const urlTrailing = ".,:;!?'\")]}"
+
+// The length of a Tweet.
+type Result struct {
+	// The NFC normalized text, which is what should be sent.
+	Text string
+	// Length in characters, with the weights applied.
+	WeightedLength int
+	// Thousandths of MAXLENGTH used.
+	Permillage int
+	// Byte offset in Text up to which the Tweet fits.  Equals len(Text)
+	// unless the Tweet is too long.
+	ValidEnd int
+	// Position of the first code point Twitter refuses, or -1.
+	InvalidAt int
+}
+
+// Returns the text in the NFC form Twitter counts and stores.
+func Normalize(text string) string {
+	return norm.NFC.String(text)
+}
+
+func weight(r rune) int {
+	for _, span := range lightRanges {
+		if r >= span[0] && r <= span[1] {
+			return SCALE
+		}
+	}
+	return DEFAULTWEIGHT
+}
+
+func isEmoji(r rune) bool {
+	return (r >= 0x1F000 && r <= 0x1FAFF) || (r >= 0x2600 && r <= 0x27BF) ||
+		(r >= 0x2B00 && r <= 0x2BFF) || r == 0x00A9 || r == 0x00AE ||
+		r == 0x203C || r == 0x2049 || r == 0x2122 || r == 0x2139 ||
+		(r >= 0x2194 && r <= 0x21AA) || (r >= 0x231A && r <= 0x23FF) ||
+		(r >= 0x25AA && r <= 0x25FE) || r == 0x3030 || r == 0x303D
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+// Code points which extend the emoji before them: variation selectors,
+// skin tones, the keycap and tag characters.
+func isEmojiModifier(r rune) bool {
+	return r == 0xFE0E || r == 0xFE0F || r == 0x20E3 ||
+		(r >= 0x1F3FB && r <= 0x1F3FF) || (r >= 0xE0020 && r <= 0xE007F)
+}
+
+// Returns the length in bytes of the emoji sequence at the start of s, or 0
+// if s does not start with one.  Keycaps like 1️⃣ start with a plain digit.
+func emojiLength(s string) int {
+	r, size := utf8.DecodeRuneInString(s)
+	next, nextSize := utf8.DecodeRuneInString(s[size:])
+	switch {
+	case isRegionalIndicator(r):
+		// Flags are pairs of regional indicators.
+		if isRegionalIndicator(next) {
+			return size + nextSize
+		}
+		return size
+	case (r >= '0' && r <= '9') || r == '#' || r == '*':
+		if next == 0xFE0F {
+			if after, afterSize := utf8.DecodeRuneInString(s[size+nextSize:]); after == 0x20E3 {
+				return size + nextSize + afterSize
+			}
+		}
+		return 0
+	case !isEmoji(r):
+		return 0
+	}
+	n := size
+	for n < len(s) {
+		r, size = utf8.DecodeRuneInString(s[n:])
+		if isEmojiModifier(r) {
+			n += size
+			continue
+		}
+		if r == 0x200D {
+			// Zero width joiner, as in 👩‍💻.
+			if next, nextSize = utf8.DecodeRuneInString(s[n+size:]); isEmoji(next) {
+				n += size + nextSize
+				continue
+			}
+		}
+		break
+	}
+	return n
+}
+
+// Returns the byte ranges of the URLs in s.
+func urls(s string) (spans [][2]int) {
+	for _, m := range urlPattern.FindAllStringIndex(s, -1) {
+		end := m[1]
+		for end > m[0] && strings.ContainsRune(urlTrailing, rune(s[end-1])) {
+			if s[end-1] == ')' && strings.Count(s[m[0]:end], "(") >= strings.Count(s[m[0]:end], ")") {
+				// Balanced parentheses, as in Wikipedia links.
+				break
+			}
+			end--
+		}
+		spans = append(spans, [2]int{m[0], end})
+	}
+	return
+}
+
+// Counts text as Twitter would.
+func Parse(text string) *Result {
+	r := &Result{Text: Normalize(text), InvalidAt: -1}
+	var (
+		s        = r.Text
+		weighted = 0
+		spans    = urls(s)
+		fits     = true
+	)
+	advance := func(w int, end int) {
+		weighted += w
+		if fits && weighted <= MAXLENGTH*SCALE {
+			r.ValidEnd = end
+		} else {
+			fits = false
+		}
+	}
+	for i := 0; i < len(s); {
+		if len(spans) > 0 && i == spans[0][0] {
+			advance(URLLENGTH*SCALE, spans[0][1])
+			i = spans[0][1]
+			spans = spans[1:]
+			continue
+		}
+		if n := emojiLength(s[i:]); n > 0 {
+			advance(DEFAULTWEIGHT, i+n)
+			i += n
+			continue
+		}
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if r.InvalidAt < 0 && strings.ContainsRune(invalidChars, c) {
+			r.InvalidAt = i
+		}
+		advance(weight(c), i+size)
+		i += size
+	}
+	r.WeightedLength = weighted / SCALE
+	r.Permillage = weighted / SCALE * 1000 / MAXLENGTH
+	return r
+}
+
+// Characters left before MAXLENGTH, negative if the Tweet is too long.
+func (r *Result) Remaining() int {
+	return MAXLENGTH - r.WeightedLength
+}
+
+// Returns nil if the Tweet can be sent, and why not otherwise.
+func (r *Result) Err() error {
+	switch {
+	case strings.TrimSpace(r.Text) == "":
+		return fmt.Errorf("Tweet is empty")
+	case r.InvalidAt >= 0:
+		c, _ := utf8.DecodeRuneInString(r.Text[r.InvalidAt:])
+		return fmt.Errorf("Tweet contains the invalid character %U at byte %v", c, r.InvalidAt)
+	case r.Remaining() < 0:
+		return fmt.Errorf("Tweet is %v over the %v character limit, it would be cut off after %q",
+			-r.Remaining(), MAXLENGTH, tail(r.Text[:r.ValidEnd], 20))
+	}
+	return nil
+}
+
+func (r *Result) String() string {
+	return fmt.Sprintf("%v/%v characters, %v left", r.WeightedLength, MAXLENGTH, r.Remaining())
+}
+
+// Returns the last n code points of s.
+func tail(s string, n int) string {
+	i := len(s)
+	for ; n > 0 && i > 0; n-- {
+		_, size := utf8.DecodeLastRuneInString(s[:i])
+		i -= size
+	}
+	if i > 0 {
+		return "…" + s[i:]
+	}
+	return s
+}
+
+// Parses text and returns its Result along with Err.
+func Validate(text string) (r *Result, err error) {
+	r = Parse(text)
+	return r, r.Err()
+}
@@ -0,0 +1,136 @@
+// Copyright 2026 Arne Roomann-Kurrik
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package twittertext
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		text   string
+		length int
+	}{
+		{"empty", "", 0},
+		{"latin", "hello", 5},
+		{"cjk", "世界", 4},
+		{"mixed", "Hello 世界 https://example.com/a/long/path", 34},
+		{"nfc", "cafe\u0301", 4},
+		{"quotes and dashes", "“a”—b", 5},
+		{"emoji", "😀", 2},
+		{"zwj sequence", "👩‍💻", 2},
+		{"skin tone", "👍🏽", 2},
+		{"flag", "🇯🇵", 2},
+		{"keycap", "1️⃣", 2},
+		{"variation selector", "❤️", 2},
+		{"plain digits", "123", 3},
+		{"long url", "https://example.com/" + strings.Repeat("x", 100), 23},
+		{"two urls", "http://a.co http://b.co", 47},
+		{"bare domain", "see golang.org", 27},
+		{"www", "www.example.com/path, ok", 27},
+		{"trailing period", "see example.com.", 28},
+		{"parentheses", "(https://golang.org)", 25},
+		{"balanced parentheses", "https://en.wikipedia.org/wiki/Go_(language)", 23},
+	}
+	for _, test := range tests {
+		if r := Parse(test.text); r.WeightedLength != test.length {
+			t.Errorf("%v: Parse(%q).WeightedLength = %v, want %v", test.name, test.text, r.WeightedLength, test.length)
+		}
+	}
+}
+
+func TestResult(t *testing.T) {
+	tests := []struct {
+		name       string
+		text       string
+		normalized string
+		remaining  int
+		permillage int
+		validEnd   int
+		invalidAt  int
+	}{
+		{"short", "hello", "hello", 275, 17, 5, -1},
+		{"nfc", "cafe\u0301", "caf\u00e9", 276, 14, 5, -1},
+		{"full", strings.Repeat("a", 280), strings.Repeat("a", 280), 0, 1000, 280, -1},
+		{"over", strings.Repeat("a", 281), strings.Repeat("a", 281), -1, 1003, 280, -1},
+		{"cjk over", strings.Repeat("世", 141), strings.Repeat("世", 141), -2, 1007, 420, -1},
+		{"invalid", "a\uFEFFb", "a\uFEFFb", 276, 14, 5, 1},
+	}
+	for _, test := range tests {
+		r := Parse(test.text)
+		if r.Text != test.normalized {
+			t.Errorf("%v: Text = %q, want %q", test.name, r.Text, test.normalized)
+		}
+		if r.Remaining() != test.remaining {
+			t.Errorf("%v: Remaining() = %v, want %v", test.name, r.Remaining(), test.remaining)
+		}
+		if r.Permillage != test.permillage {
+			t.Errorf("%v: Permillage = %v, want %v", test.name, r.Permillage, test.permillage)
+		}
+		if r.ValidEnd != test.validEnd {
+			t.Errorf("%v: ValidEnd = %v, want %v", test.name, r.ValidEnd, test.validEnd)
+		}
+		if r.InvalidAt != test.invalidAt {
+			t.Errorf("%v: InvalidAt = %v, want %v", test.name, r.InvalidAt, test.invalidAt)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		text string
+		err  string
+	}{
+		{"hello", ""},
+		{strings.Repeat("a", 280), ""},
+		{strings.Repeat("世", 140), ""},
+		{"", "empty"},
+		{" \n", "empty"},
+		{"a\uFFFEb", "invalid character U+FFFE"},
+		{strings.Repeat("a", 281), "1 over"},
+		{strings.Repeat("世", 141), "2 over"},
+	}
+	for _, test := range tests {
+		_, err := Validate(test.text)
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("Validate(%.20q) = %v, want no error", test.text, err)
+		case test.err != "" && err == nil:
+			t.Errorf("Validate(%.20q) = nil, want an error about %q", test.text, test.err)
+		case err != nil && !strings.Contains(err.Error(), test.err):
+			t.Errorf("Validate(%.20q) = %v, want an error about %q", test.text, err, test.err)
+		}
+	}
+}
+
+func TestTail(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"", 3, ""},
+		{"abc", 3, "abc"},
+		{"abcdef", 3, "…def"},
+		{"世界你好", 2, "…你好"},
+	}
+	for _, test := range tests {
+		if got := tail(test.s, test.n); got != test.want {
+			t.Errorf("tail(%q, %v) = %q, want %q", test.s, test.n, got, test.want)
+		}
+	}
+}
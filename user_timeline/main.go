@@ -108,11 +108,11 @@ package main
 //   Wrote 3036 Tweets to user_timeline.json
 
 import (
-	"encoding/json"
 	"flag"
 	"fmt"
 	"github.com/kurrik/oauth1a"
 	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
 	"io/ioutil"
 	"net/http"
 	"net/url"
@@ -139,12 +139,16 @@ func LoadCredentials() (client *twittergo.Client, err error) {
 type Args struct {
 	ScreenName string
 	OutputFile string
+	Output     string
+	Template   string
 }
 
 func parseArgs() *Args {
 	a := &Args{}
 	flag.StringVar(&a.ScreenName, "screen_name", "twitterapi", "Screen name")
 	flag.StringVar(&a.OutputFile, "out", "user_timeline.json", "Output file")
+	flag.StringVar(&a.Output, "output", output.NDJSON, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
 	flag.Parse()
 	return a
 }
@@ -160,7 +164,7 @@ func main() {
 		out     *os.File
 		query   url.Values
 		results *twittergo.Timeline
-		text    []byte
+		w       *output.Writer
 	)
 	args = parseArgs()
 	if client, err = LoadCredentials(); err != nil {
@@ -172,6 +176,11 @@ func main() {
 		os.Exit(1)
 	}
 	defer out.Close()
+	if w, err = output.NewWriter(out, args.Output, args.Template, output.TweetSchema); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
+	defer w.Close()
 	const (
 		count   int = 100
 		urltmpl     = "/1.1/statuses/user_timeline.json?%v"
@@ -188,10 +197,12 @@ func main() {
 		endpoint := fmt.Sprintf(urltmpl, query.Encode())
 		if req, err = http.NewRequest("GET", endpoint, nil); err != nil {
 			fmt.Printf("Could not parse request: %v\n", err)
+			w.Close()
 			os.Exit(1)
 		}
 		if resp, err = client.SendRequest(req); err != nil {
 			fmt.Printf("Could not send request: %v\n", err)
+			w.Close()
 			os.Exit(1)
 		}
 		results = &twittergo.Timeline{}
@@ -216,12 +227,11 @@ func main() {
 			break
 		}
 		for _, tweet := range *results {
-			if text, err = json.Marshal(tweet); err != nil {
-				fmt.Printf("Could not encode Tweet: %v\n", err)
+			if err = w.Write(tweet); err != nil {
+				fmt.Printf("Could not write Tweet: %v\n", err)
+				w.Close()
 				os.Exit(1)
 			}
-			out.Write(text)
-			out.Write([]byte("\n"))
 			max_id = tweet.Id() - 1
 			total += 1
 		}
@@ -15,9 +15,11 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/kurrik/oauth1a"
 	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/output"
 	"io/ioutil"
 	"net/http"
 	"os"
@@ -39,6 +41,19 @@ func LoadCredentials() (client *twittergo.Client, err error) {
 	return
 }
 
+type Args struct {
+	Output   string
+	Template string
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Output, "output", output.Text, output.FormatUsage)
+	flag.StringVar(&a.Template, "template", "", output.TemplateUsage)
+	flag.Parse()
+	return a
+}
+
 func main() {
 	var (
 		err    error
@@ -46,35 +61,50 @@ func main() {
 		req    *http.Request
 		resp   *twittergo.APIResponse
 		user   *twittergo.User
+		args   *Args
+		out    *output.Writer
 	)
+	args = parseArgs()
+	if out, err = output.NewWriter(os.Stdout, args.Output, args.Template, output.UserSchema); err != nil {
+		fmt.Printf("%v\n", err)
+		os.Exit(1)
+	}
 	client, err = LoadCredentials()
 	if err != nil {
 		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
 	req, err = http.NewRequest("GET", "/1.1/account/verify_credentials.json", nil)
 	if err != nil {
 		fmt.Printf("Could not parse request: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
 	resp, err = client.SendRequest(req)
 	if err != nil {
 		fmt.Printf("Could not send request: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
 	user = &twittergo.User{}
 	err = resp.Parse(user)
 	if err != nil {
 		fmt.Printf("Problem parsing response: %v\n", err)
+		out.Close()
+		os.Exit(1)
+	}
+	if err = out.Write(user); err != nil {
+		fmt.Printf("Could not write user: %v\n", err)
+		out.Close()
 		os.Exit(1)
 	}
-	fmt.Printf("ID:                   %v\n", user.Id())
-	fmt.Printf("Name:                 %v\n", user.Name())
+	out.Close()
 	if resp.HasRateLimit() {
-		fmt.Printf("Rate limit:           %v\n", resp.RateLimit())
-		fmt.Printf("Rate limit remaining: %v\n", resp.RateLimitRemaining())
-		fmt.Printf("Rate limit reset:     %v\n", resp.RateLimitReset())
+		out.Notef("Rate limit:           %v\n", resp.RateLimit())
+		out.Notef("Rate limit remaining: %v\n", resp.RateLimitRemaining())
+		out.Notef("Rate limit reset:     %v\n", resp.RateLimitReset())
 	} else {
-		fmt.Printf("Could not parse rate limit from response.\n")
+		out.Notef("Could not parse rate limit from response.\n")
 	}
 }
@@ -15,16 +15,19 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"flag"
 	"fmt"
 	"github.com/kurrik/oauth1a"
 	"github.com/kurrik/twittergo"
+	"github.com/kurrik/twittergo-examples/mediaupload"
+	"github.com/kurrik/twittergo-examples/twittertext"
 	"io"
 	"io/ioutil"
-	"mime/multipart"
 	"net/http"
 	"net/url"
 	"os"
+	"os/signal"
 	"strings"
 	"time"
 )
@@ -44,6 +47,23 @@ func LoadCredentials() (client *twittergo.Client, err error) {
 	return
 }
 
+type Args struct {
+	Status    string
+	File      string
+	MediaType string
+	ChunkSize int64
+}
+
+func parseArgs() *Args {
+	a := &Args{}
+	flag.StringVar(&a.Status, "status", fmt.Sprintf("Media! %v", time.Now()), "Text of the Tweet")
+	flag.StringVar(&a.File, "file", "video_upload/twitter_media_upload.mp4", "Video to upload")
+	flag.StringVar(&a.MediaType, "media_type", "", "MIME type of the video, detected if blank")
+	flag.Int64Var(&a.ChunkSize, "chunk_size", mediaupload.DEFAULTCHUNK, "Bytes sent per APPEND, at most 5MB")
+	flag.Parse()
+	return a
+}
+
 func SendApiRequest(client *twittergo.Client, reqUrl string, params map[string]string) (resp *twittergo.APIResponse, err error) {
 	var (
 		body io.Reader
@@ -62,100 +82,64 @@ func SendApiRequest(client *twittergo.Client, reqUrl string, params map[string]s
 	return
 }
 
-func SendMediaRequest(client *twittergo.Client, reqUrl string, params map[string]string, media []byte) (mediaResp twittergo.MediaResponse, err error) {
-	var (
-		req         *http.Request
-		resp        *twittergo.APIResponse
-		body        io.ReadWriter = bytes.NewBufferString("")
-		mp          *multipart.Writer
-		writer      io.Writer
-		contentType string
-	)
-	mp = multipart.NewWriter(body)
-	for key, value := range params {
-		mp.WriteField(key, value)
-	}
-	if media != nil {
-		if writer, err = mp.CreateFormField("media"); err != nil {
-			return
-		}
-		writer.Write(media)
-	}
-	contentType = fmt.Sprintf("multipart/form-data;boundary=%v", mp.Boundary())
-	mp.Close()
-	if req, err = http.NewRequest("POST", reqUrl, body); err != nil {
-		return
-	}
-	req.Header.Set("Content-Type", contentType)
-	if resp, err = client.SendRequest(req); err != nil {
-		return
-	}
-	err = resp.Parse(&mediaResp)
-	return
-}
-
 func main() {
 	var (
-		err        error
-		client     *twittergo.Client
-		apiResp    *twittergo.APIResponse
-		mediaResp  twittergo.MediaResponse
-		mediaId    string
-		mediaBytes []byte
+		err       error
+		client    *twittergo.Client
+		apiResp   *twittergo.APIResponse
+		mediaResp twittergo.MediaResponse
+		mediaId   string
+		status    *twittertext.Result
+		media     *mediaupload.Media
 	)
+	args := parseArgs()
 	client, err = LoadCredentials()
 	if err != nil {
 		fmt.Printf("Could not parse CREDENTIALS file: %v\n", err)
 		os.Exit(1)
 	}
-	if mediaBytes, err = ioutil.ReadFile("video_upload/twitter_media_upload.mp4"); err != nil {
-		fmt.Printf("Error reading media: %v\n", err)
+	if status, err = twittertext.Validate(args.Status); err != nil {
+		fmt.Printf("Could not post Tweet: %v\n", err)
 		os.Exit(1)
 	}
-	if mediaResp, err = SendMediaRequest(
-		client,
-		"https://upload.twitter.com/1.1/media/upload.json",
-		map[string]string{
-			"command":     "INIT",
-			"media_type":  "video/mp4",
-			"total_bytes": fmt.Sprintf("%d", len(mediaBytes)),
-		},
-		nil,
-	); err != nil {
-		fmt.Printf("Problem sending INIT request: %v\n", err)
-		os.Exit(1)
+	fmt.Printf("Length:                     %v\n", status)
+	if media, err = mediaupload.Detect(args.File); err == nil {
+		err = media.Check()
 	}
-	mediaId = fmt.Sprintf("%v", mediaResp.MediaId())
-	if mediaResp, err = SendMediaRequest(
-		client,
-		"https://upload.twitter.com/1.1/media/upload.json",
-		map[string]string{
-			"command":       "APPEND",
-			"media_id":      mediaId,
-			"segment_index": "0",
-		},
-		mediaBytes,
-	); err != nil {
-		fmt.Printf("Problem sending APPEND request: %v\n", err)
+	if err != nil {
+		fmt.Printf("Could not upload: %v\n", err)
 		os.Exit(1)
 	}
-	if mediaResp, err = SendMediaRequest(
-		client,
-		"https://upload.twitter.com/1.1/media/upload.json",
-		map[string]string{
-			"command":  "FINALIZE",
-			"media_id": mediaId,
+	fmt.Printf("Media:                      %v\n", media)
+	upload := &mediaupload.Upload{
+		Path:      args.File,
+		MediaType: args.MediaType,
+		ChunkSize: args.ChunkSize,
+		Progress: func(sent int64, total int64) {
+			fmt.Printf("Uploaded %v of %v bytes (%v%%)\n", sent, total, sent*100/total)
 		},
-		nil,
-	); err != nil {
-		fmt.Printf("Problem sending FINALIZE request: %v\n", err)
+		Processing: func(info mediaupload.ProcessingInfo) {
+			if percent := info.Progress(); percent >= 0 {
+				fmt.Printf("Processing: %v (%v%%)\n", info.State(), percent)
+			} else {
+				fmt.Printf("Processing: %v\n", info.State())
+			}
+		},
+	}
+	// Ctrl-C stops after the last whole chunk, so the upload can resume.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if mediaResp, err = upload.Run(ctx, client); err != nil {
+		fmt.Printf("Problem uploading %v: %v\n", args.File, err)
+		fmt.Printf("Run again to resume the upload.\n")
 		os.Exit(1)
 	}
+	mediaId = mediaupload.MediaId(mediaResp)
 	if apiResp, err = SendApiRequest(
 		client,
 		"/1.1/statuses/update.json",
 		map[string]string{
-			"status":    fmt.Sprintf("Media! %v", time.Now()),
+			"status":    status.Text,
 			"media_ids": mediaId,
 		},
 	); err != nil {
@@ -172,7 +156,7 @@ func main() {
 	fmt.Printf("ID:                         %v\n", tweet.Id())
 	fmt.Printf("Tweet:                      %v\n", tweet.Text())
 	fmt.Printf("User:                       %v\n", tweet.User().Name())
-	fmt.Printf("Media Id:                   %v\n", mediaResp.MediaId())
+	fmt.Printf("Media Id:                   %v\n", mediaId)
 	if apiResp.HasRateLimit() {
 		fmt.Printf("Rate limit:                 %v\n", apiResp.RateLimit())
 		fmt.Printf("Rate limit remaining:       %v\n", apiResp.RateLimitRemaining())